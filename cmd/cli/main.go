@@ -16,6 +16,22 @@ func printHeader(name string) {
 	fmt.Printf("Starting the simulation: %s...\n", name)
 }
 
+// geometryFields lists the config fields required to place the bath
+func geometryFields() []string {
+	switch conf.Physics.Geometry {
+	case "file":
+		return []string{"Geometry", "PositionsFile"}
+	case "random":
+		return []string{"Geometry", "Disorder"}
+	case "square", "triangular", "cubic":
+		return []string{"Geometry", "Lattice"}
+	case "cylinder", "double-ring":
+		return []string{"ConstantDistance", "Geometry", "Rings"}
+	default:
+		return []string{"ConstantDistance", "Geometry"}
+	}
+}
+
 func main() {
 	var configFiles []string
 	pflag.StringSliceVarP(&configFiles, "values", "f", []string{}, "specify values in a YAML file or a URL (can specify multiple)")
@@ -43,37 +59,33 @@ func main() {
 	switch conf.Simulation {
 	case "spin-evolution":
 		printHeader("spin evolution")
-		if err := cs.Validate(conf.Physics, []string{
+		if err := cs.Validate(conf.Physics, append([]string{
 			"BathDipoleMoment",
 			"AtomDipoleMoment",
 			"Spin",
 			"TiltAngle",
-			"ConstantDistance",
-			"Geometry",
 			"BathMagneticField",
 			"CentralMagneticField",
 			"TimeRange",
 			"Dt",
 			"InitialKet",
 			"ObservablesConfig",
-		}); err != nil {
+		}, geometryFields()...)); err != nil {
 			panic(err)
 		}
 		sim.SpinTimeEvolution(conf)
 	case "spread-of-couplings":
 		printHeader("spread of couplings")
-		if err := cs.Validate(conf.Physics, []string{
+		if err := cs.Validate(conf.Physics, append([]string{
 			"BathDipoleMoment",
 			"AtomDipoleMoment",
 			"BathCount",
 			"Spin",
 			"TiltAngleRange",
-			"ConstantDistance",
-			"Geometry",
 			"BathMagneticField",
 			"CentralMagneticField",
 			"Dt",
-		}); err != nil {
+		}, geometryFields()...)); err != nil {
 			panic(err)
 		}
 		sim.SpreadOfCouplingsVsTiltAngle(conf)
@@ -90,36 +102,67 @@ func main() {
 		sim.SpreadOfCouplingsVsTiltAngle(conf)
 	case "decay-time":
 		printHeader("decay time")
-		if err := cs.Validate(conf.Physics, []string{
+		if err := cs.Validate(conf.Physics, append([]string{
 			"BathDipoleMoment",
 			"AtomDipoleMoment",
 			"BathCount",
 			"Spin",
 			"TiltAngleRange",
-			"ConstantDistance",
-			"Geometry",
 			"BathMagneticField",
 			"CentralMagneticField",
 			"Dt",
-		}); err != nil {
+		}, geometryFields()...)); err != nil {
 			panic(err)
 		}
 		sim.DecayTimeVsTiltAngle(conf)
+	case "export-geometry":
+		if err := cs.Validate(conf.Export, []string{"Results"}); err != nil {
+			panic(err)
+		}
+		printHeader("export geometry")
+		sim.ExportGeometry(conf)
+	case "optimise-tilt-angle":
+		printHeader("optimal tilt angles")
+		if err := cs.Validate(conf.Physics, append([]string{
+			"BathDipoleMoment",
+			"AtomDipoleMoment",
+			"BathCount",
+			"Spin",
+			"TiltAngleRange",
+			"TiltOptimisation",
+			"BathMagneticField",
+			"CentralMagneticField",
+		}, geometryFields()...)); err != nil {
+			panic(err)
+		}
+		sim.OptimiseTiltAngle(conf)
+	case "decay-time-vs-orientation":
+		printHeader("decay time vs orientation")
+		if err := cs.Validate(conf.Physics, append([]string{
+			"BathDipoleMoment",
+			"AtomDipoleMoment",
+			"BathCount",
+			"Spin",
+			"OrientationSweep",
+			"BathMagneticField",
+			"CentralMagneticField",
+		}, geometryFields()...)); err != nil {
+			panic(err)
+		}
+		sim.DecayTimeVsOrientation(conf)
 	case "spectrum":
 		printHeader("spectrum")
 		sim.Spectrum(conf)
 	case "interactions":
-		if err := cs.Validate(conf.Physics, []string{
+		if err := cs.Validate(conf.Physics, append([]string{
 			"BathDipoleMoment",
 			"AtomDipoleMoment",
 			"BathCount",
-			"ConstantDistance",
 			"Spin",
 			"TiltAngle",
-			"Geometry",
 			"BathMagneticField",
 			"CentralMagneticField",
-		}); err != nil {
+		}, geometryFields()...)); err != nil {
 			panic(err)
 		}
 		printHeader("interactions")
@@ -148,10 +191,105 @@ func main() {
 			"BathMagneticField",
 			"CentralMagneticField",
 		}
+		switch conf.Physics.Design.Target {
+		case "gaussian", "flat":
+			s = []string{"BathDipoleMoment", "AtomDipoleMoment", "Spin", "BathCount", "Design"}
+		}
 		if err := cs.Validate(conf.Physics, s); err != nil {
 			panic(err)
 		}
 		printHeader("Find geometry for specified interactions")
 		sim.FindGeometryGivenInteractions(conf)
+	case "holstein-primakoff":
+		if err := cs.Validate(conf.Physics, []string{
+			"Spin",
+			"BathMagneticField",
+			"CentralMagneticField",
+			"TimeRange",
+			"Dt",
+			"InitialKet",
+			"ObservablesConfig",
+			"MaxBosons",
+		}); err != nil {
+			panic(err)
+		}
+		printHeader("Holstein-Primakoff spin evolution")
+		sim.HolsteinPrimakoffEvolution(conf)
+	case "spin-mode-evolution":
+		if err := cs.Validate(conf.Physics, []string{
+			"Spin",
+			"BathMagneticField",
+			"CentralMagneticField",
+			"TimeRange",
+			"Dt",
+			"InitialKet",
+			"ObservablesConfig",
+			"Mode",
+		}); err != nil {
+			panic(err)
+		}
+		printHeader("spin and bosonic mode evolution")
+		sim.SpinModeEvolution(conf)
+	case "autocorrelation":
+		if err := cs.Validate(conf.Physics, []string{
+			"Spin",
+			"BathMagneticField",
+			"CentralMagneticField",
+			"TimeRange",
+			"Dt",
+			"InitialKet",
+			"Spectral",
+		}); err != nil {
+			panic(err)
+		}
+		printHeader("autocorrelation and spectral function")
+		sim.Autocorrelation(conf)
+	case "level-statistics":
+		if err := cs.Validate(conf.Physics, []string{
+			"BathCount",
+			"Spin",
+			"LevelStatistics",
+		}); err != nil {
+			panic(err)
+		}
+		printHeader("level statistics")
+		sim.LevelStatistics(conf)
+	case "otoc":
+		if err := cs.Validate(conf.Physics, []string{
+			"Spin",
+			"BathMagneticField",
+			"CentralMagneticField",
+			"TimeRange",
+			"Dt",
+			"InitialKet",
+			"Otoc",
+		}); err != nil {
+			panic(err)
+		}
+		printHeader("out-of-time-order correlators")
+		sim.OutOfTimeOrderCorrelators(conf)
+	case "quantum-fisher":
+		if err := cs.Validate(conf.Physics, []string{
+			"Spin",
+			"BathMagneticField",
+			"CentralMagneticField",
+			"TimeRange",
+			"Dt",
+			"InitialKet",
+			"Fisher",
+		}); err != nil {
+			panic(err)
+		}
+		printHeader("quantum Fisher information")
+		sim.QuantumFisher(conf)
+	case "ensemble":
+		if err := cs.Validate(conf.Physics, append([]string{
+			"Spin",
+			"Ensemble",
+		}, geometryFields()...)); err != nil {
+			panic(err)
+		}
+		printHeader("disorder ensemble")
+		sim.Ensemble(conf)
 	}
 }
@@ -0,0 +1,77 @@
+package cs_q_sim
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Bosonic operators act on the Fock space |0>, |1>, ..., |maxBosons> truncated at maxBosons quanta.
+
+func BosonId(maxBosons int) *mat.Dense {
+	dim := maxBosons + 1
+	data := mat.NewDense(dim, dim, nil)
+	for i := 0; i < dim; i++ {
+		data.Set(i, i, 1)
+	}
+	return data
+}
+
+// Annihilation returns the truncated bosonic lowering operator a, with a|n> = sqrt(n)|n-1>
+func Annihilation(maxBosons int) *mat.Dense {
+	dim := maxBosons + 1
+	data := mat.NewDense(dim, dim, nil)
+	for n := 1; n < dim; n++ {
+		data.Set(n-1, n, math.Sqrt(float64(n)))
+	}
+	return data
+}
+
+// Creation returns the truncated bosonic raising operator a†, with a†|n> = sqrt(n+1)|n+1>
+func Creation(maxBosons int) *mat.Dense {
+	dim := maxBosons + 1
+	data := mat.NewDense(dim, dim, nil)
+	for n := 1; n < dim; n++ {
+		data.Set(n, n-1, math.Sqrt(float64(n)))
+	}
+	return data
+}
+
+// Number returns the bosonic number operator a†a
+func Number(maxBosons int) *mat.Dense {
+	dim := maxBosons + 1
+	data := mat.NewDense(dim, dim, nil)
+	for n := 0; n < dim; n++ {
+		data.Set(n, n, float64(n))
+	}
+	return data
+}
+
+// FockVector returns the Fock state |n> in the space truncated at maxBosons quanta
+func FockVector(n, maxBosons int) []float64 {
+	if n < 0 || n > maxBosons {
+		panic("Fock state exceeds the boson truncation")
+	}
+	v := make([]float64, maxBosons+1)
+	v[n] = 1.0
+	return v
+}
+
+// SplitFockState splits a ket written as e.g. "duuu|2>" into its spin part "duuu" and the Fock number 2 of the bosonic mode.
+// If the ket has no Fock part, hasMode is false
+func SplitFockState(ket string) (spins string, fock int, hasMode bool) {
+	i := strings.IndexRune(ket, '|')
+	if i < 0 {
+		return ket, 0, false
+	}
+	if !strings.HasSuffix(ket, ">") {
+		panic("Fock state should be written as |n>")
+	}
+	fock, err := strconv.Atoi(ket[i+1 : len(ket)-1])
+	if err != nil {
+		panic(err)
+	}
+	return ket[:i], fock, true
+}
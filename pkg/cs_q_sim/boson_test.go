@@ -0,0 +1,89 @@
+package cs_q_sim
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestAnnihilation(t *testing.T) {
+	type args struct {
+		maxBosons int
+	}
+	tests := []struct {
+		name string
+		args args
+		want *mat.Dense
+	}{
+		{
+			name: "two quanta",
+			args: args{maxBosons: 2},
+			want: mat.NewDense(3, 3, []float64{
+				0.0, 1.0, 0.0,
+				0.0, 0.0, math.Sqrt2,
+				0.0, 0.0, 0.0,
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Annihilation(tt.args.maxBosons); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Annihilation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreation(t *testing.T) {
+	type args struct {
+		maxBosons int
+	}
+	tests := []struct {
+		name string
+		args args
+		want *mat.Dense
+	}{
+		{
+			name: "two quanta",
+			args: args{maxBosons: 2},
+			want: mat.NewDense(3, 3, []float64{
+				0.0, 0.0, 0.0,
+				1.0, 0.0, 0.0,
+				0.0, math.Sqrt2, 0.0,
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Creation(tt.args.maxBosons); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Creation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumber(t *testing.T) {
+	type args struct {
+		maxBosons int
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "a†a",
+			args: args{maxBosons: 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want mat.Dense
+			want.Mul(Creation(tt.args.maxBosons), Annihilation(tt.args.maxBosons))
+			if got := Number(tt.args.maxBosons); !mat.EqualApprox(got, &want, 1e-12) {
+				t.Errorf("Number() = %v, want %v", got, want)
+			}
+		})
+	}
+}
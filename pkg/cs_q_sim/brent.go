@@ -0,0 +1,213 @@
+package cs_q_sim
+
+import (
+	"fmt"
+	"math"
+)
+
+// Extremum is a local optimum or a root of a function of one variable. Boundary marks optima found at an end of the searched range
+type Extremum struct {
+	X, F     float64
+	Boundary bool
+}
+
+// cgold is the golden section ratio used by the minimiser when parabolic steps fail
+var cgold = 0.5 * (3 - math.Sqrt(5))
+
+// BrentMinimize returns the minimum of f in [a, b] by Brent's method, combining golden section search and parabolic interpolation,
+// to the absolute tolerance 'tol' in x
+func BrentMinimize(f func(float64) float64, a, b, tol float64) (float64, float64) {
+	if a > b {
+		a, b = b, a
+	}
+	x := a + cgold*(b-a)
+	w, v := x, x
+	fx := f(x)
+	fw, fv := fx, fx
+	var d, e float64
+	for iter := 0; iter < 500; iter++ {
+		m := 0.5 * (a + b)
+		tol1 := tol + 1e-12*math.Abs(x)
+		tol2 := 2 * tol1
+		if math.Abs(x-m) <= tol2-0.5*(b-a) {
+			break
+		}
+		golden := true
+		if math.Abs(e) > tol1 {
+			r := (x - w) * (fx - fv)
+			q := (x - v) * (fx - fw)
+			p := (x-v)*q - (x-w)*r
+			q = 2 * (q - r)
+			if q > 0 {
+				p = -p
+			}
+			q = math.Abs(q)
+			if math.Abs(p) < math.Abs(0.5*q*e) && p > q*(a-x) && p < q*(b-x) {
+				e, d = d, p/q
+				if u := x + d; u-a < tol2 || b-u < tol2 {
+					d = math.Copysign(tol1, m-x)
+				}
+				golden = false
+			}
+		}
+		if golden {
+			if x < m {
+				e = b - x
+			} else {
+				e = a - x
+			}
+			d = cgold * e
+		}
+		u := x + d
+		if math.Abs(d) < tol1 {
+			u = x + math.Copysign(tol1, d)
+		}
+		fu := f(u)
+		if fu <= fx {
+			if u < x {
+				b = x
+			} else {
+				a = x
+			}
+			v, fv, w, fw, x, fx = w, fw, x, fx, u, fu
+		} else {
+			if u < x {
+				a = u
+			} else {
+				b = u
+			}
+			if fu <= fw || w == x {
+				v, fv, w, fw = w, fw, u, fu
+			} else if fu <= fv || v == x || v == w {
+				v, fv = u, fu
+			}
+		}
+	}
+	return x, fx
+}
+
+// BrentRoot returns a root of f in [a, b], where f(a) and f(b) should differ in sign, by the Brent-Dekker method to the tolerance 'tol'
+func BrentRoot(f func(float64) float64, a, b, tol float64) (float64, error) {
+	fa, fb := f(a), f(b)
+	if fa == 0 {
+		return a, nil
+	}
+	if fb == 0 {
+		return b, nil
+	}
+	if (fa > 0) == (fb > 0) {
+		return 0, fmt.Errorf("f(%v) = %v and f(%v) = %v do not bracket a root", a, fa, b, fb)
+	}
+	c, fc := b, fb
+	var d, e float64
+	for iter := 0; iter < 500; iter++ {
+		if (fb > 0) == (fc > 0) {
+			c, fc = a, fa
+			d = b - a
+			e = d
+		}
+		if math.Abs(fc) < math.Abs(fb) {
+			a, b, c = b, c, b
+			fa, fb, fc = fb, fc, fb
+		}
+		tol1 := tol + 1e-15*math.Abs(b)
+		m := 0.5 * (c - b)
+		if math.Abs(m) <= tol1 || fb == 0 {
+			return b, nil
+		}
+		if math.Abs(e) >= tol1 && math.Abs(fa) > math.Abs(fb) {
+			// inverse quadratic interpolation, or the secant step if only two points are distinct
+			var p, q float64
+			s := fb / fa
+			if a == c {
+				p = 2 * m * s
+				q = 1 - s
+			} else {
+				q = fa / fc
+				r := fb / fc
+				p = s * (2*m*q*(q-r) - (b-a)*(r-1))
+				q = (q - 1) * (r - 1) * (s - 1)
+			}
+			if p > 0 {
+				q = -q
+			}
+			p = math.Abs(p)
+			if 2*p < math.Min(3*m*q-math.Abs(tol1*q), math.Abs(e*q)) {
+				e, d = d, p/q
+			} else {
+				d, e = m, m
+			}
+		} else {
+			d, e = m, m
+		}
+		a, fa = b, fb
+		if math.Abs(d) > tol1 {
+			b += d
+		} else {
+			b += math.Copysign(tol1, m)
+		}
+		fb = f(b)
+	}
+	return b, nil
+}
+
+// sampleGrid evaluates f at samples+1 evenly spaced points spanning [a, b]
+func sampleGrid(f func(float64) float64, a, b float64, samples int) ([]float64, []float64) {
+	if samples < 2 {
+		samples = 2
+	}
+	xs := make([]float64, samples+1)
+	fs := make([]float64, samples+1)
+	for i := range xs {
+		xs[i] = a + (b-a)*float64(i)/float64(samples)
+		fs[i] = f(xs[i])
+	}
+	return xs, fs
+}
+
+/*
+LocalMinima brackets the local minima of f on a grid of 'samples' intervals spanning [a, b] and refines each with BrentMinimize to the tolerance 'tol'.
+Minima at the ends of the range are reported with Boundary set. Flat stretches of the grid count as a single minimum
+*/
+func LocalMinima(f func(float64) float64, a, b float64, samples int, tol float64) []Extremum {
+	xs, fs := sampleGrid(f, a, b, samples)
+	n := len(xs) - 1
+	var minima []Extremum
+	if fs[0] < fs[1] {
+		minima = append(minima, Extremum{X: xs[0], F: fs[0], Boundary: true})
+	}
+	for i := 1; i < n; i++ {
+		if fs[i] <= fs[i-1] && fs[i] < fs[i+1] {
+			x, fx := BrentMinimize(f, xs[i-1], xs[i+1], tol)
+			if fx > fs[i] {
+				x, fx = xs[i], fs[i]
+			}
+			minima = append(minima, Extremum{X: x, F: fx})
+		}
+	}
+	if fs[n] < fs[n-1] {
+		minima = append(minima, Extremum{X: xs[n], F: fs[n], Boundary: true})
+	}
+	return minima
+}
+
+// Roots brackets the sign changes of f on a grid of 'samples' intervals spanning [a, b] and refines each with BrentRoot to the tolerance 'tol'
+func Roots(f func(float64) float64, a, b float64, samples int, tol float64) []Extremum {
+	xs, fs := sampleGrid(f, a, b, samples)
+	var roots []Extremum
+	for i := 0; i+1 < len(xs); i++ {
+		switch {
+		case fs[i] == 0:
+			roots = append(roots, Extremum{X: xs[i]})
+		case (fs[i] > 0) != (fs[i+1] > 0) && fs[i+1] != 0:
+			x, err := BrentRoot(f, xs[i], xs[i+1], tol)
+			if err == nil {
+				roots = append(roots, Extremum{X: x, F: f(x)})
+			}
+		}
+	}
+	if last := len(xs) - 1; fs[last] == 0 {
+		roots = append(roots, Extremum{X: xs[last]})
+	}
+	return roots
+}
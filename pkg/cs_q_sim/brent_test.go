@@ -0,0 +1,70 @@
+package cs_q_sim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBrentMinimize(t *testing.T) {
+	tests := []struct {
+		name  string
+		f     func(float64) float64
+		a, b  float64
+		wantX float64
+	}{
+		{name: "parabola", f: func(x float64) float64 { return (x - 1.3) * (x - 1.3) }, a: 0, b: 3, wantX: 1.3},
+		{name: "cosine", f: math.Cos, a: 2, b: 4, wantX: math.Pi},
+		{name: "kink", f: func(x float64) float64 { return math.Abs(x - 0.2) }, a: -1, b: 1, wantX: 0.2},
+		{name: "reversed bounds", f: func(x float64) float64 { return math.Pow(x+2, 4) }, a: 0, b: -5, wantX: -2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, fx := BrentMinimize(tt.f, tt.a, tt.b, 1e-10)
+			if math.Abs(x-tt.wantX) > 1e-4 || fx != tt.f(x) {
+				t.Errorf("BrentMinimize() = (%v, %v), want x = %v", x, fx, tt.wantX)
+			}
+		})
+	}
+}
+
+func TestBrentRoot(t *testing.T) {
+	x, err := BrentRoot(func(x float64) float64 { return x*x*x - 2 }, 0, 2, 1e-14)
+	if err != nil || math.Abs(x-math.Cbrt(2)) > 1e-12 {
+		t.Errorf("BrentRoot() = %v, %v, want %v", x, err, math.Cbrt(2))
+	}
+	if _, err := BrentRoot(func(x float64) float64 { return x*x + 1 }, -1, 1, 1e-12); err == nil {
+		t.Errorf("BrentRoot() should fail without a bracket")
+	}
+}
+
+func TestLocalMinima(t *testing.T) {
+	// sin on [0, 4π] has minima at 1.5π and 3.5π, and rises from the lower end of the range
+	got := LocalMinima(math.Sin, 0, 4*math.Pi, 50, 1e-10)
+	want := []Extremum{{X: 0, F: 0, Boundary: true}, {X: 1.5 * math.Pi, F: -1}, {X: 3.5 * math.Pi, F: -1}}
+	if len(got) != len(want) {
+		t.Fatalf("LocalMinima() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if math.Abs(got[i].X-want[i].X) > 1e-5 || math.Abs(got[i].F-want[i].F) > 1e-10 || got[i].Boundary != want[i].Boundary {
+			t.Errorf("LocalMinima()[%v] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	boundary := LocalMinima(func(x float64) float64 { return x }, 0, 1, 10, 1e-10)
+	if len(boundary) != 1 || !boundary[0].Boundary || boundary[0].X != 0 {
+		t.Errorf("LocalMinima() of a rising line = %v, want the lower end", boundary)
+	}
+}
+
+func TestRoots(t *testing.T) {
+	got := Roots(math.Cos, 0, 3*math.Pi, 30, 1e-12)
+	want := []float64{0.5 * math.Pi, 1.5 * math.Pi, 2.5 * math.Pi}
+	if len(got) != len(want) {
+		t.Fatalf("Roots() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if math.Abs(got[i].X-want[i]) > 1e-10 {
+			t.Errorf("Roots()[%v] = %v, want %v", i, got[i].X, want[i])
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package cs_q_sim
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ModeDims returns the local dimensions of the central spin, the bath spins and a bosonic mode truncated at maxBosons quanta, in this order
+func (s *System) ModeDims(maxBosons int) []int {
+	spinDim := int(2.0*s.PhysicsConfig.Spin + 1.0)
+	dims := make([]int, len(s.Bath)+2)
+	for i := range dims {
+		dims[i] = spinDim
+	}
+	dims[len(dims)-1] = maxBosons + 1
+	return dims
+}
+
+/*
+HamiltonianWithMode returns the Hamiltonian of the spins coupled to a single truncated bosonic mode (a cavity or a motional mode),
+the mode occupying the last slot of the Hilbert space:
+
+	H = H_spins ⊗ 1 + ω a†a + H_coupling
+
+with H_coupling = g (S_0^+ a + S_0^- a†) for the Jaynes-Cummings coupling and g S_0^z (a + a†) for the "sz" coupling
+*/
+func (s *System) HamiltonianWithMode(b0, b float64, mode ModeConfig) *mat.SymDense {
+	spin := s.PhysicsConfig.Spin
+	dims := s.ModeDims(mode.MaxBosons)
+	modeSlot := len(dims) - 1
+
+	var h mat.Dense
+	spins := s.Hamiltonian(b0, b)
+	h.Kronecker(mat.DenseCopyOf(spins), BosonId(mode.MaxBosons))
+
+	number := ManyBodyOperatorWithDims(Number(mode.MaxBosons), modeSlot, dims)
+	number.Scale(mode.Frequency, number)
+	h.Add(&h, number)
+
+	var coupling mat.Dense
+	switch mode.Coupling {
+	case "jaynes-cummings":
+		var conj mat.Dense
+		coupling.Mul(ManyBodyOperatorWithDims(Sp(spin), 0, dims), ManyBodyOperatorWithDims(Annihilation(mode.MaxBosons), modeSlot, dims))
+		conj.Mul(ManyBodyOperatorWithDims(Sm(spin), 0, dims), ManyBodyOperatorWithDims(Creation(mode.MaxBosons), modeSlot, dims))
+		coupling.Add(&coupling, &conj)
+	case "sz":
+		var displacement mat.Dense
+		displacement.Add(Annihilation(mode.MaxBosons), Creation(mode.MaxBosons))
+		coupling.Mul(ManyBodyOperatorWithDims(Sz(spin), 0, dims), ManyBodyOperatorWithDims(&displacement, modeSlot, dims))
+	default:
+		panic("unknown mode coupling: " + mode.Coupling)
+	}
+	coupling.Scale(mode.CouplingStrength, &coupling)
+	h.Add(&h, &coupling)
+
+	if !mat.EqualApprox(&h, h.T(), 1e-8) {
+		panic("Hamiltonian is not symmetric.")
+	}
+	return mat.NewSymDense(h.RawMatrix().Cols, h.RawMatrix().Data)
+}
+
+// ModeVector returns the product state of the spins given by a ket such as "duuu" and the Fock state |fock> of a mode truncated at maxBosons quanta
+func ModeVector(spins string, spin float64, fock, maxBosons int) *mat.VecDense {
+	spinDim := int(2*spin + 1)
+	spinsDim := int(math.Pow(float64(spinDim), float64(len(spins))))
+	var v mat.Dense
+	v.Kronecker(mat.NewDense(spinsDim, 1, ManyBodyVector(spins, spinDim)), mat.NewDense(maxBosons+1, 1, FockVector(fock, maxBosons)))
+	return mat.NewVecDense(spinsDim*(maxBosons+1), v.RawMatrix().Data)
+}
@@ -0,0 +1,99 @@
+package cs_q_sim
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestManyBodyOperatorWithDims(t *testing.T) {
+	type args struct {
+		operator *mat.Dense
+		particle int
+		dims     []int
+	}
+	tests := []struct {
+		name string
+		args args
+		want *mat.Dense
+	}{
+		{
+			name: "uniform dims agree with ManyBodyOperator",
+			args: args{operator: Sz(0.5), particle: 1, dims: []int{2, 2, 2}},
+			want: ManyBodyOperator(Sz(0.5), 1, 3),
+		},
+		{
+			name: "spin next to a mode",
+			args: args{operator: Number(2), particle: 1, dims: []int{2, 3}},
+			want: mat.NewDense(6, 6, []float64{
+				0, 0, 0, 0, 0, 0,
+				0, 1, 0, 0, 0, 0,
+				0, 0, 2, 0, 0, 0,
+				0, 0, 0, 0, 0, 0,
+				0, 0, 0, 0, 1, 0,
+				0, 0, 0, 0, 0, 2,
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ManyBodyOperatorWithDims(tt.args.operator, tt.args.particle, tt.args.dims); !mat.Equal(got, tt.want) {
+				t.Errorf("ManyBodyOperatorWithDims() = %v, want %v", mat.Formatted(got), mat.Formatted(tt.want))
+			}
+		})
+	}
+}
+
+func TestSplitFockState(t *testing.T) {
+	tests := []struct {
+		name        string
+		ket         string
+		wantSpins   string
+		wantFock    int
+		wantHasMode bool
+	}{
+		{name: "spins only", ket: "duuu", wantSpins: "duuu", wantFock: 0, wantHasMode: false},
+		{name: "with a mode", ket: "duuu|3>", wantSpins: "duuu", wantFock: 3, wantHasMode: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spins, fock, hasMode := SplitFockState(tt.ket)
+			if spins != tt.wantSpins || fock != tt.wantFock || hasMode != tt.wantHasMode {
+				t.Errorf("SplitFockState() = %v, %v, %v, want %v, %v, %v", spins, fock, hasMode, tt.wantSpins, tt.wantFock, tt.wantHasMode)
+			}
+		})
+	}
+}
+
+func TestSystem_HamiltonianWithMode(t *testing.T) {
+	tests := []struct {
+		name string
+		mode ModeConfig
+	}{
+		{
+			name: "Jaynes-Cummings conserves the number of excitations",
+			mode: ModeConfig{MaxBosons: 3, Frequency: 1.0, Coupling: "jaynes-cummings", CouplingStrength: 0.3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &System{
+				Bath:          []State{{}, {}},
+				PhysicsConfig: PhysicsConfig{Spin: 0.5, InteractionCoefficients: []float64{0.0, 1.0, 0.4}},
+			}
+			h := s.HamiltonianWithMode(1.0, 0.9, tt.mode)
+			dims := s.ModeDims(tt.mode.MaxBosons)
+
+			excitations := ManyBodyOperatorWithDims(Number(tt.mode.MaxBosons), len(dims)-1, dims)
+			for j := 0; j < len(dims)-1; j++ {
+				excitations.Add(excitations, ManyBodyOperatorWithDims(Sz(0.5), j, dims))
+			}
+			var left, right mat.Dense
+			left.Mul(h, excitations)
+			right.Mul(excitations, h)
+			if !mat.EqualApprox(&left, &right, 1e-10) {
+				t.Errorf("[H, N] != 0")
+			}
+		})
+	}
+}
@@ -10,29 +10,251 @@ import (
 )
 
 type PhysicsConfig struct {
-	BathDipoleMoment        float64            `mapstructure:"bathdipolemoment"`
-	AtomDipoleMoment        float64            `mapstructure:"atomdipolemoment"`
-	BathCount               int                `mapstructure:"bathcount"`
-	Spin                    float64            `mapstructure:"spin"`
-	TiltAngle               float64            `mapstructure:"tiltangle"`
-	TiltAngleRange          []float64          `mapstructure:"tiltanglerange"`
-	ConstantDistance        float64            `mapstructure:"constantdistance"`
-	Geometry                string             `mapstructure:"geometry"`
-	InteractionCoefficients []float64          `mapstructure:"interactioncoefficients"`
-	BathMagneticField       float64            `mapstructure:"bathmagneticfield"`
-	CentralMagneticField    float64            `mapstructure:"centralmagneticfield"`
-	Model                   string             `mapstructure:"model"`
-	TimeRange               int                `mapstructure:"timerange"`
-	Dt                      float64            `mapstructure:"dt"`
-	InitialKet              string             `mapstructure:"initialket"`
-	ObservablesConfig       []ObservableConfig `mapstructure:"observables"`
-	MagneticFieldRange      int                `mapstructure:"magneticfieldrange"`
-	Units                   string             `mapstructure:"units"`
+	BathDipoleMoment        float64                `mapstructure:"bathdipolemoment"`
+	AtomDipoleMoment        float64                `mapstructure:"atomdipolemoment"`
+	BathCount               int                    `mapstructure:"bathcount"`
+	Spin                    float64                `mapstructure:"spin"`
+	TiltAngle               float64                `mapstructure:"tiltangle"`
+	TiltAngleRange          []float64              `mapstructure:"tiltanglerange"`
+	ConstantDistance        float64                `mapstructure:"constantdistance"`
+	Geometry                string                 `mapstructure:"geometry"`
+	PositionsFile           string                 `mapstructure:"positionsfile"` // XYZ or CSV bath positions for the "file" geometry
+	PositionUnits           string                 `mapstructure:"positionunits"` // unit of the positions unless the file states one
+	Disorder                DisorderConfig         `mapstructure:"disorder"`
+	Ensemble                EnsembleConfig         `mapstructure:"ensemble"`
+	Lattice                 LatticeConfig          `mapstructure:"lattice"`
+	Rings                   RingsConfig            `mapstructure:"rings"`
+	Orientation             OrientationConfig      `mapstructure:"orientation"`
+	OrientationSweep        OrientationSweepConfig `mapstructure:"orientationsweep"`
+	Design                  DesignConfig           `mapstructure:"design"`
+	TiltOptimisation        TiltOptimisationConfig `mapstructure:"tiltoptimisation"`
+	InteractionCoefficients []float64              `mapstructure:"interactioncoefficients"`
+	Couplings               CouplingsConfig        `mapstructure:"couplings"` // generates InteractionCoefficients at load time
+	BathMagneticField       float64                `mapstructure:"bathmagneticfield"`
+	CentralMagneticField    float64                `mapstructure:"centralmagneticfield"`
+	Model                   string                 `mapstructure:"model"`
+	TimeRange               int                    `mapstructure:"timerange"`
+	Dt                      float64                `mapstructure:"dt"`
+	InitialKet              string                 `mapstructure:"initialket"`
+	ObservablesConfig       []ObservableConfig     `mapstructure:"observables"`
+	MagneticFieldRange      int                    `mapstructure:"magneticfieldrange"`
+	Units                   string                 `mapstructure:"units"`
+	UnitSystem              UnitSystemConfig       `mapstructure:"unitsystem"`
+	BathSpecies             SpeciesConfig          `mapstructure:"bathspecies"` // preset of the bath dipole moment, field and g-factor
+	AtomSpecies             SpeciesConfig          `mapstructure:"atomspecies"` // preset of the central atom
+	MaxBosons               int                    `mapstructure:"maxbosons"`   // truncation of the Holstein-Primakoff collective mode
+	Mode                    ModeConfig             `mapstructure:"mode"`
+	Correlations            []CorrelationConfig    `mapstructure:"correlations"`
+	Entanglement            []EntanglementConfig   `mapstructure:"entanglement"`
+	Loschmidt               LoschmidtConfig        `mapstructure:"loschmidt"`
+	CentralSpinState        bool                   `mapstructure:"centralspinstate"` // reduced density matrix, Bloch vector, purity and l1-coherence of the central spin
+	Spectral                SpectralConfig         `mapstructure:"spectral"`
+	LevelStatistics         LevelStatisticsConfig  `mapstructure:"levelstatistics"`
+	Otoc                    OtocConfig             `mapstructure:"otoc"`
+	Fisher                  FisherConfig           `mapstructure:"fisher"`
 }
 
+// DisorderConfig draws the bath of the "random" geometry uniformly inside a ball (or a disc in the xy plane if Dimension is 2) around the central atom.
+// The radius follows from Density (sites per unit volume or area, in the distance unit) or, if Density is zero, from FillingFraction,
+// the fraction of the volume covered by hard spheres of diameter ExclusionRadius. No two sites, the central atom included, are closer than ExclusionRadius
+type DisorderConfig struct {
+	Density         float64 `mapstructure:"density"`
+	FillingFraction float64 `mapstructure:"fillingfraction"`
+	ExclusionRadius float64 `mapstructure:"exclusionradius"`
+	Dimension       int     `mapstructure:"dimension"` // 3 when unset
+	Seed            int64   `mapstructure:"seed"`
+}
+
+// EnsembleConfig repeats Simulation (spin-evolution or spread-of-couplings) over Realisations disorder realisations,
+// seeded with Seeds, or with Disorder.Seed, Disorder.Seed + 1, ... when Seeds are not given. The seed of a realisation draws
+// both the random geometry and the lattice occupation
+type EnsembleConfig struct {
+	Simulation   string  `mapstructure:"simulation"`
+	Realisations int     `mapstructure:"realisations"`
+	Seeds        []int64 `mapstructure:"seeds"`
+}
+
+// LatticeConfig shapes the square, triangular and cubic geometries. Lengths are in the distance unit.
+// Occupation is an explicit mask over the sites within CutoffRadius (see LatticeSites); without it the occupied sites are drawn with Seed
+type LatticeConfig struct {
+	LatticeConstant float64 `mapstructure:"latticeconstant"`
+	CutoffRadius    float64 `mapstructure:"cutoffradius"`
+	FillingFraction float64 `mapstructure:"fillingfraction"` // sets the cut-off radius when it is not given
+	Occupation      []int   `mapstructure:"occupation"`
+	Seed            int64   `mapstructure:"seed"`
+}
+
+// RingsConfig shapes the cylinder (Layers rings stacked Spacing apart along z) and the double-ring geometry (two coplanar rings,
+// the outer one RadiusRatio times larger). With Staggered every other ring is turned by half a site spacing
+type RingsConfig struct {
+	Layers      int     `mapstructure:"layers"`
+	Spacing     float64 `mapstructure:"spacing"`
+	RadiusRatio float64 `mapstructure:"radiusratio"`
+	Staggered   bool    `mapstructure:"staggered"`
+}
+
+// OrientationConfig turns the geometry about the central atom, either by the z-y-z Euler angles Euler = (alpha, beta, gamma)
+// or by Angle about Axis, with all angles in units of π. Field, given as (polar, azimuth) in units of π, points the magnetic field
+// in the laboratory frame and overrides TiltAngle, so the simulations that vary the tilt angle reject it
+type OrientationConfig struct {
+	Euler []float64 `mapstructure:"euler"`
+	Axis  []float64 `mapstructure:"axis"`
+	Angle float64   `mapstructure:"angle"`
+	Field []float64 `mapstructure:"field"`
+}
+
+// OrientationSweepConfig samples the field direction on a PolarSteps × AzimuthSteps grid spanning PolarRange and AzimuthRange (in units of π)
+type OrientationSweepConfig struct {
+	PolarRange   []float64 `mapstructure:"polarrange"`
+	AzimuthRange []float64 `mapstructure:"azimuthrange"`
+	PolarSteps   int       `mapstructure:"polarsteps"`
+	AzimuthSteps int       `mapstructure:"azimuthsteps"`
+}
+
+// DesignConfig sets up the inverse design of the bath geometry (see DesignGeometry): the Target couplings ("coefficients", "gaussian"
+// or "flat", the latter two centred at Mean with a width of Width), the smallest allowed distance MinSpacing between sites, in the
+// distance unit, and the Restarts seeded from Seed, each with at most MaxIterations BFGS iterations (1000 if unset)
+type DesignConfig struct {
+	Target        string  `mapstructure:"target"`
+	Mean          float64 `mapstructure:"mean"`
+	Width         float64 `mapstructure:"width"`
+	MinSpacing    float64 `mapstructure:"minspacing"`
+	Restarts      int     `mapstructure:"restarts"`
+	Seed          int64   `mapstructure:"seed"`
+	MaxIterations int     `mapstructure:"maxiterations"`
+}
+
+// TiltOptimisationConfig looks for the tilt angles within TiltAngleRange where Quantity ("spread", in units of 10^3, or "decay-time")
+// is minimal or maximal (Goal "min" or "max", by default the smallest spread and the longest decay time) or equals Target (Goal "target").
+// The candidates are bracketed on a grid of Samples intervals (100 if unset) and refined by Brent's method to Tolerance (1e-10 if unset)
+type TiltOptimisationConfig struct {
+	Quantity  string  `mapstructure:"quantity"`
+	Goal      string  `mapstructure:"goal"`
+	Target    float64 `mapstructure:"target"`
+	Samples   int     `mapstructure:"samples"`
+	Tolerance float64 `mapstructure:"tolerance"`
+}
+
+// CouplingsConfig selects a family of coupling profiles (see CouplingProfile) and its parameters
+type CouplingsConfig struct {
+	Profile  string  `mapstructure:"profile"`
+	Width    float64 `mapstructure:"width"`
+	Scale    float64 `mapstructure:"scale"`
+	Count    int     `mapstructure:"count"`
+	Exponent float64 `mapstructure:"exponent"`
+	Seed     int64   `mapstructure:"seed"`
+}
+
+/*
+UnitSystemConfig declares the units of the bare numbers in the config, in place of Units: Dipole (D, ea0 or C m) for the dipole moments,
+Distance (m, nm, um, a0, ...) for the distances and positions, Field (a frequency unit, or T, mT, G) for the magnetic fields and
+Time (s, ms, us, ns) for Dt. Frequency (Hz, kHz, MHz or GHz) is the internal unit of the couplings, the fields and the interaction coefficients,
+and times are measured in its reciprocal. Fields in tesla or gauss turn into frequencies with the gyromagnetic ratios in Hz/T,
+by default that of the free electron
+*/
+type UnitSystemConfig struct {
+	Dipole                   string  `mapstructure:"dipole"`
+	Distance                 string  `mapstructure:"distance"`
+	Field                    string  `mapstructure:"field"`
+	Time                     string  `mapstructure:"time"`
+	Frequency                string  `mapstructure:"frequency"`
+	BathGyromagneticRatio    float64 `mapstructure:"bathgyromagneticratio"`
+	CentralGyromagneticRatio float64 `mapstructure:"centralgyromagneticratio"`
+}
+
+// SpeciesConfig names a species preset (see LookupSpecies): a molecule such as KRb, NaK, RbCs, NaCs or CaF, or a Rydberg atom
+// (Rb or Cs) in the state with principal quantum number N, orbital angular momentum L and total angular momentum J
+type SpeciesConfig struct {
+	Name string  `mapstructure:"name"`
+	N    int     `mapstructure:"n"`
+	L    int     `mapstructure:"l"`
+	J    float64 `mapstructure:"j"`
+}
+
+// ModeConfig describes a truncated harmonic-oscillator mode (a cavity or a motional mode) coupled to the central spin
+type ModeConfig struct {
+	MaxBosons        int     `mapstructure:"maxbosons"`
+	Frequency        float64 `mapstructure:"frequency"`
+	Coupling         string  `mapstructure:"coupling"` // jaynes-cummings or sz
+	CouplingStrength float64 `mapstructure:"couplingstrength"`
+}
+
+// ObservableConfig is either a single Operator at Slot or an Expression over site operators (see ParseOperator), which takes precedence
 type ObservableConfig struct {
-	Operator string `mapstructure:"operator"`
-	Slot     int    `mapstructure:"slot"`
+	Operator   string `mapstructure:"operator"`
+	Slot       int    `mapstructure:"slot"`
+	Expression string `mapstructure:"expression"`
+}
+
+// Label names the observable in results
+func (o ObservableConfig) Label() string {
+	if o.Expression != "" {
+		return "<" + o.Expression + ">"
+	}
+	return fmt.Sprintf("<%v_%v>", o.Operator, o.Slot)
+}
+
+// CorrelationConfig selects two-point correlators <A_i B_j> of the operators A, B.
+// Either a single pair of Slots is given, or Pairs is "central-bath" (i = 0, every bath j) or "bath-bath" (the full bath correlation matrix)
+type CorrelationConfig struct {
+	Operators []string `mapstructure:"operators"`
+	Slots     []int    `mapstructure:"slots"`
+	Pairs     string   `mapstructure:"pairs"`
+	Connected bool     `mapstructure:"connected"` // <A_i B_j> - <A_i><B_j>
+}
+
+// EntanglementConfig selects a bipartition and the entanglement measures tracked for it.
+// Partition is "central" (central spin vs bath), "half-bath" (the first half of the bath vs the rest) or "sites" with the kept Sites listed.
+// Quantities are any of vonneumann, renyi2, purity and spectrum, all of them when empty
+type EntanglementConfig struct {
+	Partition  string   `mapstructure:"partition"`
+	Sites      []int    `mapstructure:"sites"`
+	Quantities []string `mapstructure:"quantities"`
+}
+
+// LoschmidtConfig adds the return probability and the Loschmidt echo against a perturbed Hamiltonian,
+// which differs by a tilt angle offset and/or a jitter added to the coupling of one bath slot
+type LoschmidtConfig struct {
+	ReturnProbability bool    `mapstructure:"returnprobability"`
+	TiltAngleOffset   float64 `mapstructure:"tiltangleoffset"`
+	JitterSlot        int     `mapstructure:"jitterslot"`
+	Jitter            float64 `mapstructure:"jitter"`
+}
+
+// SpectralConfig selects the autocorrelation <A(t) B(0)> of two operators at Slot, and the grid and broadening of its spectral function
+type SpectralConfig struct {
+	Operators       []string  `mapstructure:"operators"` // Sp, Sm when empty
+	Slot            int       `mapstructure:"slot"`
+	Broadening      string    `mapstructure:"broadening"` // lorentzian or gaussian
+	Width           float64   `mapstructure:"width"`
+	FrequencyRange  []float64 `mapstructure:"frequencyrange"` // (min, max)
+	FrequencyPoints int       `mapstructure:"frequencypoints"`
+}
+
+// LevelStatisticsConfig selects the swept parameter ("tiltangle" over TiltAngleRange, or "magneticfield", which scales both configured
+// fields by 0, 1, ..., MagneticFieldRange - 1) and how the level spacings are unfolded and binned, all three of which have to be positive
+type LevelStatisticsConfig struct {
+	Sweep           string  `mapstructure:"sweep"`
+	UnfoldingDegree int     `mapstructure:"unfoldingdegree"`
+	HistogramBins   int     `mapstructure:"histogrambins"`
+	MaxSpacing      float64 `mapstructure:"maxspacing"`
+}
+
+// OtocConfig selects the operator W at WSlot and the operator V, placed in turn at every slot of VSlots (every bath slot when empty),
+// of the out-of-time-order correlators F(t) = <W†(t) V† W(t) V>
+type OtocConfig struct {
+	W      string `mapstructure:"w"`
+	WSlot  int    `mapstructure:"wslot"`
+	V      string `mapstructure:"v"`
+	VSlots []int  `mapstructure:"vslots"`
+}
+
+// FisherConfig selects the parameter of the quantum Fisher information: centralmagneticfield, bathmagneticfield, tiltangle,
+// or coupling (of the bath spin at Slot). ∂H is taken as a central difference of the Hamiltonian with the given Step
+type FisherConfig struct {
+	Parameter string  `mapstructure:"parameter"`
+	Slot      int     `mapstructure:"slot"`
+	Step      float64 `mapstructure:"step"`
 }
 
 type FilesConfig struct {
@@ -52,6 +274,16 @@ type Config struct {
 	Verbosity  string        `mapstructure:"verbosity"` // debug for more verbosity
 	Physics    PhysicsConfig `mapstructure:"physics"`
 	Files      FilesConfig   `mapstructure:"files"`
+	Export     ExportConfig  `mapstructure:"export"`
+}
+
+// ExportConfig selects the results file (in OutputsDir, without the .yaml extension) whose geometry is exported to FigDir
+// in the given Formats: png, svg, pdf or eps renderings, xyz and obj (all but pdf and eps when empty). The rendering looks
+// at the geometry from View = (azimuth, elevation), in units of π
+type ExportConfig struct {
+	Results string    `mapstructure:"results"`
+	Formats []string  `mapstructure:"formats"`
+	View    []float64 `mapstructure:"view"`
 }
 
 var vp *viper.Viper
@@ -77,6 +309,15 @@ func LoadConfig(additionalPath []string) Config {
 	if err != nil {
 		parse(err)
 	}
+	if err := applySpecies(&config.Physics); err != nil {
+		parse(err)
+	}
+	if err := applyUnitSystem(&config.Physics); err != nil {
+		parse(err)
+	}
+	if err := applyCouplingProfile(&config.Physics); err != nil {
+		parse(err)
+	}
 
 	return config
 }
@@ -0,0 +1,88 @@
+package cs_q_sim
+
+import (
+	"math/cmplx"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// EmbedInFullSpace maps a state written in the basis restricted by BasisIndices back onto the full Hilbert space of dimension dim
+func EmbedInFullSpace(state []complex128, indices []int, dim int) []complex128 {
+	full := make([]complex128, dim)
+	for i, index := range indices {
+		full[index] = state[i]
+	}
+	return full
+}
+
+// ApplySiteOperator returns O_site |state> for a one-body operator O acting on slot 'site' out of 'sites' slots of equal local dimension.
+// It works on the indices of the state directly, so the many-body operator is never built
+func ApplySiteOperator(operator *mat.Dense, site, sites int, state []complex128) []complex128 {
+	localDim, _ := operator.Dims()
+	stride := 1
+	for i := site + 1; i < sites; i++ {
+		stride *= localDim
+	}
+	out := make([]complex128, len(state))
+	for index, amplitude := range state {
+		if amplitude == 0 {
+			continue
+		}
+		col := (index / stride) % localDim
+		base := index - col*stride
+		for row := 0; row < localDim; row++ {
+			if el := operator.At(row, col); el != 0 {
+				out[base+row*stride] += complex(el, 0) * amplitude
+			}
+		}
+	}
+	return out
+}
+
+// InnerProduct returns <a|b>
+func InnerProduct(a, b []complex128) complex128 {
+	var sum complex128
+	for i := range a {
+		sum += cmplx.Conj(a[i]) * b[i]
+	}
+	return sum
+}
+
+// TwoPointCorrelator returns <state| A_i B_j |state> = <A_i^† state | B_j state>
+func TwoPointCorrelator(a, b *mat.Dense, i, j, sites int, state []complex128) complex128 {
+	return InnerProduct(ApplySiteOperator(mat.DenseCopyOf(a.T()), i, sites, state), ApplySiteOperator(b, j, sites, state))
+}
+
+// ConnectedCorrelator returns <A_i B_j> - <A_i><B_j>
+func ConnectedCorrelator(a, b *mat.Dense, i, j, sites int, state []complex128) complex128 {
+	ai := InnerProduct(state, ApplySiteOperator(a, i, sites, state))
+	bj := InnerProduct(state, ApplySiteOperator(b, j, sites, state))
+	return TwoPointCorrelator(a, b, i, j, sites, state) - ai*bj
+}
+
+/*
+CorrelationMatrix returns the matrix C_kl = <A_{slots[k]} B_{slots[l]}>, or its connected version.
+The vectors A_i^† |state> and B_j |state> are computed once per slot, so the whole matrix costs len(slots) operator applications and len(slots)^2 inner products
+*/
+func CorrelationMatrix(a, b *mat.Dense, slots []int, sites int, state []complex128, connected bool) *mat.CDense {
+	aDagger := mat.DenseCopyOf(a.T())
+	left := make([][]complex128, len(slots))
+	right := make([][]complex128, len(slots))
+	aMeans := make([]complex128, len(slots))
+	bMeans := make([]complex128, len(slots))
+	for k, slot := range slots {
+		left[k] = ApplySiteOperator(aDagger, slot, sites, state)
+		right[k] = ApplySiteOperator(b, slot, sites, state)
+		if connected {
+			aMeans[k] = cmplx.Conj(InnerProduct(state, left[k]))
+			bMeans[k] = InnerProduct(state, right[k])
+		}
+	}
+	c := mat.NewCDense(len(slots), len(slots), nil)
+	for k := range slots {
+		for l := range slots {
+			c.Set(k, l, InnerProduct(left[k], right[l])-aMeans[k]*bMeans[l])
+		}
+	}
+	return c
+}
@@ -0,0 +1,108 @@
+package cs_q_sim
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func realState(data []float64) []complex128 {
+	state := make([]complex128, len(data))
+	for i, v := range data {
+		state[i] = complex(v, 0)
+	}
+	return state
+}
+
+func TestApplySiteOperator(t *testing.T) {
+	type args struct {
+		operator *mat.Dense
+		site     int
+		sites    int
+		ket      string
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{name: "Sp on the middle of three", args: args{operator: Sp(0.5), site: 1, sites: 3, ket: "ddu"}},
+		{name: "Sm on the central spin", args: args{operator: Sm(0.5), site: 0, sites: 4, ket: "upmu"}},
+		{name: "Sz on the last slot", args: args{operator: Sz(0.5), site: 2, sites: 3, ket: "pmd"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := ManyBodyVector(tt.args.ket, 2)
+			want := mat.NewVecDense(len(data), nil)
+			want.MulVec(ManyBodyOperator(tt.args.operator, tt.args.site, tt.args.sites), mat.NewVecDense(len(data), data))
+
+			got := ApplySiteOperator(tt.args.operator, tt.args.site, tt.args.sites, realState(data))
+			for i := range got {
+				if cmplx.Abs(got[i]-complex(want.AtVec(i), 0)) > 1e-12 {
+					t.Errorf("ApplySiteOperator() = %v, want %v", got, want.RawVector().Data)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestTwoPointCorrelator(t *testing.T) {
+	type args struct {
+		a, b *mat.Dense
+		i, j int
+		ket  string
+	}
+	tests := []struct {
+		name          string
+		args          args
+		want          complex128
+		wantConnected complex128
+	}{
+		{
+			name:          "antiparallel Sz Sz",
+			args:          args{a: Sz(0.5), b: Sz(0.5), i: 0, j: 1, ket: "ud"},
+			want:          -0.25,
+			wantConnected: 0.0,
+		},
+		{
+			name:          "Sp Sm on a product state",
+			args:          args{a: Sp(0.5), b: Sm(0.5), i: 0, j: 1, ket: "ppu"},
+			want:          0.25,
+			wantConnected: 0.0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := realState(ManyBodyVector(tt.args.ket, 2))
+			sites := len(tt.args.ket)
+			if got := TwoPointCorrelator(tt.args.a, tt.args.b, tt.args.i, tt.args.j, sites, state); cmplx.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("TwoPointCorrelator() = %v, want %v", got, tt.want)
+			}
+			if got := ConnectedCorrelator(tt.args.a, tt.args.b, tt.args.i, tt.args.j, sites, state); cmplx.Abs(got-tt.wantConnected) > 1e-12 {
+				t.Errorf("ConnectedCorrelator() = %v, want %v", got, tt.wantConnected)
+			}
+		})
+	}
+}
+
+func TestCorrelationMatrix(t *testing.T) {
+	// singlet between slots 1 and 2, central spin up
+	state := []complex128{0, complex(1/math.Sqrt2, 0), complex(-1/math.Sqrt2, 0), 0, 0, 0, 0, 0}
+	slots := []int{0, 1, 2}
+	for _, connected := range []bool{false, true} {
+		c := CorrelationMatrix(Sp(0.5), Sm(0.5), slots, 3, state, connected)
+		for k, i := range slots {
+			for l, j := range slots {
+				want := TwoPointCorrelator(Sp(0.5), Sm(0.5), i, j, 3, state)
+				if connected {
+					want = ConnectedCorrelator(Sp(0.5), Sm(0.5), i, j, 3, state)
+				}
+				if cmplx.Abs(c.At(k, l)-want) > 1e-12 {
+					t.Errorf("CorrelationMatrix(connected=%v)[%v][%v] = %v, want %v", connected, k, l, c.At(k, l), want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package cs_q_sim
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+/*
+CouplingProfile generates the interaction coefficients of the profile family cc.Profile for cc.Count bath spins, with a leading 0.0 for
+the central spin. The couplings follow A(j) = Scale·Count·k(j) / Σ k(i), j = 1, ..., Count, so that they average to Scale, with
+
+	gaussian     k(j) = exp(-(j·Width/Count)²)
+	exponential  k(j) = exp(-j·Width/Count)
+	lorentzian   k(j) = 1 / (1 + (j·Width/Count)²)
+	power-law    k(j) = j^(-Exponent)
+	uniform      k(j) drawn uniformly from [1 - Width, 1 + Width] with the random seed Seed
+
+The gaussian family reproduces scripts/gaussian_coeffs.py with Width = B and Scale = x1
+*/
+func CouplingProfile(cc CouplingsConfig) ([]float64, error) {
+	if cc.Count < 1 {
+		return nil, fmt.Errorf("a coupling profile needs a positive count, got %v", cc.Count)
+	}
+	n := float64(cc.Count)
+	var k func(j int) float64
+	switch cc.Profile {
+	case "gaussian":
+		k = func(j int) float64 { return math.Exp(-math.Pow(float64(j)*cc.Width/n, 2)) }
+	case "exponential":
+		k = func(j int) float64 { return math.Exp(-float64(j) * cc.Width / n) }
+	case "lorentzian":
+		k = func(j int) float64 { return 1 / (1 + math.Pow(float64(j)*cc.Width/n, 2)) }
+	case "power-law":
+		k = func(j int) float64 { return math.Pow(float64(j), -cc.Exponent) }
+	case "uniform":
+		if cc.Width < 0 || cc.Width > 1 {
+			return nil, fmt.Errorf("the width of the uniform profile should be within [0, 1], got %v", cc.Width)
+		}
+		rng := rand.New(rand.NewSource(cc.Seed))
+		draws := make([]float64, cc.Count+1)
+		for j := 1; j <= cc.Count; j++ {
+			draws[j] = 1 - cc.Width + 2*cc.Width*rng.Float64()
+		}
+		k = func(j int) float64 { return draws[j] }
+	default:
+		return nil, fmt.Errorf("unknown coupling profile %q, expected gaussian, exponential, lorentzian, power-law or uniform", cc.Profile)
+	}
+
+	norm := 0.0
+	for j := 1; j <= cc.Count; j++ {
+		norm += k(j)
+	}
+	if norm == 0 {
+		return nil, fmt.Errorf("the %v profile vanishes for all %v bath spins", cc.Profile, cc.Count)
+	}
+	coefficients := make([]float64, cc.Count+1)
+	for j := 1; j <= cc.Count; j++ {
+		coefficients[j] = cc.Scale * n * k(j) / norm
+	}
+	return coefficients, nil
+}
+
+// applyCouplingProfile replaces the interaction coefficients with the generated profile, if any, and sets the bath count to match
+func applyCouplingProfile(conf *PhysicsConfig) error {
+	cc := conf.Couplings
+	if cc.Profile == "" {
+		return nil
+	}
+	if conf.BathCount != 0 && conf.BathCount != cc.Count {
+		return fmt.Errorf("bathcount %v does not match the %v couplings of the profile", conf.BathCount, cc.Count)
+	}
+	// the time evolutions take their bath count from the initial ket
+	if conf.InitialKet != "" && len(conf.InitialKet)-1 != cc.Count {
+		return fmt.Errorf("initialket %v has %v bath spins, but the profile has %v couplings", conf.InitialKet, len(conf.InitialKet)-1, cc.Count)
+	}
+	coefficients, err := CouplingProfile(cc)
+	if err != nil {
+		return err
+	}
+	conf.InteractionCoefficients = coefficients
+	conf.BathCount = cc.Count
+	return nil
+}
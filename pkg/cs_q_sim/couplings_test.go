@@ -0,0 +1,82 @@
+package cs_q_sim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCouplingProfile(t *testing.T) {
+	// config/examples/gauss-b-1.465-x1-ring.yaml, generated with scripts/gaussian_coeffs.py (where B = 1.469)
+	gauss := []float64{0.0, 515301.33303097106, 492647.70358227624, 457083.0577533033, 411563.8771436377, 359635.73629538465, 304980.37568205607,
+		250994.62106951303, 200465.849335108, 155381.69217340357, 116880.68810861012, 85323.59335281285, 60447.58842258909}
+	tests := []struct {
+		name    string
+		cc      CouplingsConfig
+		want    []float64
+		wantErr bool
+	}{
+		{name: "gaussian", cc: CouplingsConfig{Profile: "gaussian", Width: 1.469, Scale: 284225.5096624722, Count: 12}, want: gauss},
+		{name: "exponential", cc: CouplingsConfig{Profile: "exponential", Width: 2 * math.Ln2, Scale: 3, Count: 2}, want: []float64{0, 4, 2}},
+		{name: "lorentzian", cc: CouplingsConfig{Profile: "lorentzian", Width: math.Sqrt2, Scale: 3, Count: 2}, want: []float64{0, 4, 2}},
+		{name: "power-law", cc: CouplingsConfig{Profile: "power-law", Exponent: 1, Scale: 3, Count: 2}, want: []float64{0, 4, 2}},
+		{name: "flat uniform", cc: CouplingsConfig{Profile: "uniform", Scale: 2, Count: 3}, want: []float64{0, 2, 2, 2}},
+		{name: "no bath", cc: CouplingsConfig{Profile: "gaussian", Scale: 1}, wantErr: true},
+		{name: "unknown profile", cc: CouplingsConfig{Profile: "cauchy", Count: 2}, wantErr: true},
+		{name: "too wide uniform", cc: CouplingsConfig{Profile: "uniform", Width: 1.5, Count: 2}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CouplingProfile(tt.cc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CouplingProfile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("CouplingProfile() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9*math.Max(1, math.Abs(tt.want[i])) {
+					t.Errorf("CouplingProfile() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestCouplingProfile_Uniform(t *testing.T) {
+	cc := CouplingsConfig{Profile: "uniform", Width: 0.5, Scale: 10, Count: 50, Seed: 4}
+	a, _ := CouplingProfile(cc)
+	b, _ := CouplingProfile(cc)
+	mean := 0.0
+	for j := 1; j <= cc.Count; j++ {
+		if a[j] != b[j] {
+			t.Fatalf("the same seed should give the same couplings")
+		}
+		mean += a[j] / float64(cc.Count)
+	}
+	if math.Abs(mean-cc.Scale) > 1e-9 {
+		t.Errorf("mean coupling = %v, want %v", mean, cc.Scale)
+	}
+}
+
+func TestApplyCouplingProfile(t *testing.T) {
+	conf := PhysicsConfig{InteractionCoefficients: []float64{0, 1}, Couplings: CouplingsConfig{Profile: "power-law", Scale: 1, Count: 3}}
+	if err := applyCouplingProfile(&conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.BathCount != 3 || len(conf.InteractionCoefficients) != 4 {
+		t.Errorf("applyCouplingProfile() gave bathcount %v and coefficients %v", conf.BathCount, conf.InteractionCoefficients)
+	}
+	conf.BathCount = 5
+	if err := applyCouplingProfile(&conf); err == nil {
+		t.Errorf("applyCouplingProfile() should fail for a mismatched bathcount")
+	}
+	conf.BathCount, conf.InitialKet = 3, "duuu"
+	if err := applyCouplingProfile(&conf); err != nil {
+		t.Errorf("applyCouplingProfile() error = %v for a matching initialket", err)
+	}
+	conf.InitialKet = "duuuu"
+	if err := applyCouplingProfile(&conf); err == nil {
+		t.Errorf("applyCouplingProfile() should fail for an initialket longer than the profile")
+	}
+}
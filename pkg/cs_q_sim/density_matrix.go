@@ -0,0 +1,169 @@
+package cs_q_sim
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// DensityMatrix is a Hermitian matrix stored as its real (symmetric) and imaginary (antisymmetric) parts
+type DensityMatrix struct {
+	Re *mat.Dense
+	Im *mat.Dense
+}
+
+func (r DensityMatrix) At(i, j int) complex128 {
+	return complex(r.Re.At(i, j), r.Im.At(i, j))
+}
+
+/*
+ReducedDensityMatrix traces out every slot not listed in 'keep' from the pure state |state> of 'sites' slots with local dimension localDim.
+The kept slots are ordered as in 'keep', so ρ_A[a, a'] = Σ_b ψ[a, b] ψ*[a', b]
+*/
+func ReducedDensityMatrix(state []complex128, keep []int, sites, localDim int) DensityMatrix {
+	isKept := make([]bool, sites)
+	for _, site := range keep {
+		isKept[site] = true
+	}
+	dimA := int(math.Pow(float64(localDim), float64(len(keep))))
+	dimB := len(state) / dimA
+
+	// psi[a][b] with a running over the kept slots and b over the traced ones
+	psi := make([][]complex128, dimA)
+	for a := range psi {
+		psi[a] = make([]complex128, dimB)
+	}
+	digits := make([]int, sites)
+	for index, amplitude := range state {
+		rest := index
+		for site := sites - 1; site >= 0; site-- {
+			digits[site] = rest % localDim
+			rest /= localDim
+		}
+		a, b := 0, 0
+		for _, site := range keep {
+			a = a*localDim + digits[site]
+		}
+		for site := 0; site < sites; site++ {
+			if !isKept[site] {
+				b = b*localDim + digits[site]
+			}
+		}
+		psi[a][b] = amplitude
+	}
+
+	rho := DensityMatrix{Re: mat.NewDense(dimA, dimA, nil), Im: mat.NewDense(dimA, dimA, nil)}
+	for a := 0; a < dimA; a++ {
+		for a2 := a; a2 < dimA; a2++ {
+			var el complex128
+			for b := 0; b < dimB; b++ {
+				el += psi[a][b] * cmplx.Conj(psi[a2][b])
+			}
+			rho.Re.Set(a, a2, real(el))
+			rho.Im.Set(a, a2, imag(el))
+			rho.Re.Set(a2, a, real(el))
+			rho.Im.Set(a2, a, -imag(el))
+		}
+	}
+	return rho
+}
+
+// Eigenvalues returns the spectrum of the density matrix in descending order, i.e. the entanglement spectrum of a reduced density matrix.
+// The Hermitian matrix Re + i Im is diagonalised through the real symmetric matrix [[Re, -Im], [Im, Re]], whose spectrum is that of ρ with every eigenvalue doubled
+func (r DensityMatrix) Eigenvalues() []float64 {
+	dim, _ := r.Re.Dims()
+	embedded := mat.NewSymDense(2*dim, nil)
+	for i := 0; i < dim; i++ {
+		for j := i; j < dim; j++ {
+			embedded.SetSym(i, j, r.Re.At(i, j))
+			embedded.SetSym(dim+i, dim+j, r.Re.At(i, j))
+			embedded.SetSym(i, dim+j, -r.Im.At(i, j))
+			embedded.SetSym(j, dim+i, -r.Im.At(j, i))
+		}
+	}
+	var eig mat.EigenSym
+	if ok := eig.Factorize(embedded, false); !ok {
+		panic("cannot diagonalize")
+	}
+	doubled := eig.Values(nil)
+	sort.Sort(sort.Reverse(sort.Float64Slice(doubled)))
+	values := make([]float64, dim)
+	for i := range values {
+		values[i] = doubled[2*i]
+	}
+	return values
+}
+
+// Purity returns Tr ρ^2
+func (r DensityMatrix) Purity() float64 {
+	return math.Pow(r.Re.Norm(2), 2) + math.Pow(r.Im.Norm(2), 2)
+}
+
+// VonNeumannEntropy returns -Tr ρ ln ρ
+func (r DensityMatrix) VonNeumannEntropy() float64 {
+	entropy := 0.0
+	for _, p := range r.Eigenvalues() {
+		if p > 1e-14 {
+			entropy -= p * math.Log(p)
+		}
+	}
+	return entropy
+}
+
+// RenyiEntropy returns ln(Tr ρ^α) / (1 - α); for α = 2 this is -ln of the purity
+func (r DensityMatrix) RenyiEntropy(alpha float64) float64 {
+	if alpha == 1 {
+		return r.VonNeumannEntropy()
+	}
+	if alpha == 2 {
+		return -math.Log(r.Purity())
+	}
+	sum := 0.0
+	for _, p := range r.Eigenvalues() {
+		if p > 1e-14 {
+			sum += math.Pow(p, alpha)
+		}
+	}
+	return math.Log(sum) / (1 - alpha)
+}
+
+// SpinExpectation returns (Tr ρ Sx, Tr ρ Sy, Tr ρ Sz) of a single spin. Since Sx + iSy = S+, both transverse components follow from Tr ρ S+
+func (r DensityMatrix) SpinExpectation(spin float64) [3]float64 {
+	sp := Sp(spin)
+	sz := Sz(spin)
+	dim, _ := r.Re.Dims()
+	var plus complex128
+	z := 0.0
+	for i := 0; i < dim; i++ {
+		for j := 0; j < dim; j++ {
+			plus += r.At(i, j) * complex(sp.At(j, i), 0)
+			z += r.Re.At(i, j) * sz.At(j, i)
+		}
+	}
+	return [3]float64{real(plus), imag(plus), z}
+}
+
+// BlochVector returns the spin expectation scaled by 1/S, so that pure spin-coherent states lie on the unit sphere
+func (r DensityMatrix) BlochVector(spin float64) [3]float64 {
+	v := r.SpinExpectation(spin)
+	for i := range v {
+		v[i] /= spin
+	}
+	return v
+}
+
+// L1Coherence returns the l1-norm of coherence Σ_{i≠j} |ρ_ij| in the Sz basis
+func (r DensityMatrix) L1Coherence() float64 {
+	dim, _ := r.Re.Dims()
+	coherence := 0.0
+	for i := 0; i < dim; i++ {
+		for j := 0; j < dim; j++ {
+			if i != j {
+				coherence += cmplx.Abs(r.At(i, j))
+			}
+		}
+	}
+	return coherence
+}
@@ -0,0 +1,172 @@
+package cs_q_sim
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestReducedDensityMatrix(t *testing.T) {
+	type args struct {
+		state []complex128
+		keep  []int
+		sites int
+	}
+	tests := []struct {
+		name string
+		args args
+		want [][]complex128
+	}{
+		{
+			name: "complex Bell pair",
+			args: args{
+				state: []complex128{0, complex(1/math.Sqrt2, 0), complex(0, 1/math.Sqrt2), 0},
+				keep:  []int{0},
+				sites: 2,
+			},
+			want: [][]complex128{{0.5, 0}, {0, 0.5}},
+		},
+		{
+			name: "coherent central spin",
+			args: args{
+				state: realState(ManyBodyVector("pud", 2)),
+				keep:  []int{0},
+				sites: 3,
+			},
+			want: [][]complex128{{0.5, 0.5}, {0.5, 0.5}},
+		},
+		{
+			name: "kept slots are reordered",
+			args: args{
+				state: realState(ManyBodyVector("udu", 2)),
+				keep:  []int{1, 0},
+				sites: 3,
+			},
+			want: [][]complex128{{0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ReducedDensityMatrix(tt.args.state, tt.args.keep, tt.args.sites, 2)
+			for i := range tt.want {
+				for j := range tt.want[i] {
+					if cmplx.Abs(got.At(i, j)-tt.want[i][j]) > 1e-12 {
+						t.Errorf("ReducedDensityMatrix()[%v][%v] = %v, want %v", i, j, got.At(i, j), tt.want[i][j])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDensityMatrix_Entropies(t *testing.T) {
+	tests := []struct {
+		name           string
+		state          []complex128
+		keep           []int
+		sites          int
+		wantSpectrum   []float64
+		wantVonNeumann float64
+		wantPurity     float64
+	}{
+		{
+			name:           "Bell pair",
+			state:          []complex128{0, complex(1/math.Sqrt2, 0), complex(0, -1/math.Sqrt2), 0},
+			keep:           []int{1},
+			sites:          2,
+			wantSpectrum:   []float64{0.5, 0.5},
+			wantVonNeumann: math.Log(2),
+			wantPurity:     0.5,
+		},
+		{
+			name:           "product state",
+			state:          realState(ManyBodyVector("pmu", 2)),
+			keep:           []int{0},
+			sites:          3,
+			wantSpectrum:   []float64{1, 0},
+			wantVonNeumann: 0,
+			wantPurity:     1,
+		},
+		{
+			name:           "unequal Schmidt weights",
+			state:          []complex128{complex(math.Sqrt(0.8), 0), 0, 0, complex(0, math.Sqrt(0.2))},
+			keep:           []int{0},
+			sites:          2,
+			wantSpectrum:   []float64{0.8, 0.2},
+			wantVonNeumann: -0.8*math.Log(0.8) - 0.2*math.Log(0.2),
+			wantPurity:     0.68,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rho := ReducedDensityMatrix(tt.state, tt.keep, tt.sites, 2)
+			for i, p := range rho.Eigenvalues() {
+				if math.Abs(p-tt.wantSpectrum[i]) > 1e-10 {
+					t.Errorf("DensityMatrix.Eigenvalues() = %v, want %v", rho.Eigenvalues(), tt.wantSpectrum)
+				}
+			}
+			if got := rho.VonNeumannEntropy(); math.Abs(got-tt.wantVonNeumann) > 1e-10 {
+				t.Errorf("DensityMatrix.VonNeumannEntropy() = %v, want %v", got, tt.wantVonNeumann)
+			}
+			if got := rho.Purity(); math.Abs(got-tt.wantPurity) > 1e-10 {
+				t.Errorf("DensityMatrix.Purity() = %v, want %v", got, tt.wantPurity)
+			}
+			if got, want := rho.RenyiEntropy(2), -math.Log(tt.wantPurity); math.Abs(got-want) > 1e-10 {
+				t.Errorf("DensityMatrix.RenyiEntropy(2) = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDensityMatrix_BlochVector(t *testing.T) {
+	tests := []struct {
+		name          string
+		state         []complex128
+		sites         int
+		wantBloch     [3]float64
+		wantCoherence float64
+	}{
+		{
+			name:          "central spin along x",
+			state:         realState(ManyBodyVector("pud", 2)),
+			sites:         3,
+			wantBloch:     [3]float64{1, 0, 0},
+			wantCoherence: 1,
+		},
+		{
+			name:          "central spin along y",
+			state:         []complex128{complex(1/math.Sqrt2, 0), 0, complex(0, 1/math.Sqrt2), 0},
+			sites:         2,
+			wantBloch:     [3]float64{0, 1, 0},
+			wantCoherence: 1,
+		},
+		{
+			name:          "central spin down",
+			state:         realState(ManyBodyVector("du", 2)),
+			sites:         2,
+			wantBloch:     [3]float64{0, 0, -1},
+			wantCoherence: 0,
+		},
+		{
+			name:          "maximally entangled",
+			state:         []complex128{0, complex(1/math.Sqrt2, 0), complex(1/math.Sqrt2, 0), 0},
+			sites:         2,
+			wantBloch:     [3]float64{0, 0, 0},
+			wantCoherence: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rho := ReducedDensityMatrix(tt.state, []int{0}, tt.sites, 2)
+			got := rho.BlochVector(0.5)
+			for i := range got {
+				if math.Abs(got[i]-tt.wantBloch[i]) > 1e-12 {
+					t.Errorf("DensityMatrix.BlochVector() = %v, want %v", got, tt.wantBloch)
+				}
+			}
+			if got := rho.L1Coherence(); math.Abs(got-tt.wantCoherence) > 1e-12 {
+				t.Errorf("DensityMatrix.L1Coherence() = %v, want %v", got, tt.wantCoherence)
+			}
+		})
+	}
+}
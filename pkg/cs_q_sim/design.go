@@ -0,0 +1,254 @@
+package cs_q_sim
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/diff/fd"
+	"gonum.org/v1/gonum/optimize"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// spacingPenalty weighs the squared relative violations of the minimum spacing against the squared relative coupling errors.
+// It grows tenfold, up to maxSpacingPenalty, while the sites end up closer than (1 - spacingTolerance) times the minimum spacing
+const (
+	spacingPenalty    = 100.0
+	maxSpacingPenalty = 1e12
+	spacingTolerance  = 1e-6
+)
+
+// Design is a bath geometry found by DesignGeometry. Targets[i] is the coupling that site i was matched with, Residual the
+// root mean square of Couplings - Targets relative to the largest target, and MinSpacing the smallest distance between two sites,
+// the central atom included
+type Design struct {
+	Positions  []Position
+	Couplings  []float64
+	Targets    []float64
+	Residual   float64
+	MinSpacing float64
+}
+
+/*
+DesignTargets returns the bath couplings requested by conf.Design: the InteractionCoefficients of the bath for the "coefficients" target,
+or BathCount quantiles of a normal distribution ("gaussian", with mean Mean and standard deviation Width) or of a uniform one
+("flat", between Mean - Width and Mean + Width) in ascending order
+*/
+func DesignTargets(conf PhysicsConfig) ([]float64, error) {
+	dc := conf.Design
+	switch dc.Target {
+	case "coefficients":
+		if len(conf.InteractionCoefficients) < 2 {
+			return nil, fmt.Errorf("the coefficients target needs interactioncoefficients for the central spin and at least one bath site")
+		}
+		return append([]float64(nil), conf.InteractionCoefficients[1:]...), nil
+	case "gaussian", "flat":
+		if conf.BathCount < 1 {
+			return nil, fmt.Errorf("the %v target needs a positive bathcount", dc.Target)
+		}
+		targets := make([]float64, conf.BathCount)
+		normal := distuv.Normal{Mu: dc.Mean, Sigma: dc.Width}
+		for i := range targets {
+			q := (float64(i) + 0.5) / float64(conf.BathCount)
+			if dc.Target == "flat" || dc.Width == 0 {
+				targets[i] = dc.Mean + dc.Width*(2*q-1)
+			} else {
+				targets[i] = normal.Quantile(q)
+			}
+		}
+		return targets, nil
+	}
+	return nil, fmt.Errorf("unknown design target %q, expected coefficients, gaussian or flat", dc.Target)
+}
+
+/*
+DesignGeometry searches for bath positions whose dipolar couplings to the central spin match DesignTargets, keeping the sites
+Design.MinSpacing apart from each other and from the central atom. Site i is matched with target i for the "coefficients" target,
+while for a distribution only the sorted couplings are matched with the sorted targets.
+
+Each of Design.Restarts runs (one if unset) starts from a seeded random placement that already reproduces the targets up to the spacing,
+and minimises the squared relative errors plus a penalty on the spacing with BFGS, raising the penalty until the spacing holds.
+The run with the smallest errors among those keeping the spacing is returned. If none does, the closest run is returned with an error
+*/
+func DesignGeometry(conf PhysicsConfig) (Design, error) {
+	targets, err := DesignTargets(conf)
+	if err != nil {
+		return Design{}, err
+	}
+	scale := 0.0
+	mean := 0.0
+	for _, t := range targets {
+		scale = math.Max(scale, math.Abs(t))
+		mean += math.Abs(t) / float64(len(targets))
+	}
+	if scale == 0 {
+		return Design{}, fmt.Errorf("the design targets should not all vanish")
+	}
+	ordered := conf.Design.Target == "coefficients"
+
+	// at unit distance along the axis the coupling is -2 halfStrength, so a site at distance r and angle θ couples with halfStrength (1 - 3 cos²θ) / r³
+	axis := QuantisationAxis(conf)
+	halfStrength := math.Abs(DipolarCoupling(conf, State{Angle: 1, Distance: 1})) / 2
+	if halfStrength == 0 {
+		return Design{}, fmt.Errorf("the dipole moments should not vanish")
+	}
+	r0 := math.Cbrt(2 * halfStrength / mean)
+	spacing := conf.Design.MinSpacing / r0
+
+	positions := func(x []float64) []Position {
+		ps := make([]Position, len(x)/3)
+		for i := range ps {
+			ps[i] = Position{x[3*i], x[3*i+1], x[3*i+2]}.Scale(r0)
+		}
+		return ps
+	}
+	couplings := func(ps []Position) []float64 {
+		cs := make([]float64, len(ps))
+		for i, p := range ps {
+			cs[i] = DipolarCoupling(conf, NewState(p, axis))
+		}
+		return cs
+	}
+	matched := func(cs []float64) []float64 {
+		if ordered {
+			return targets
+		}
+		rank := make([]int, len(cs))
+		for i := range rank {
+			rank[i] = i
+		}
+		sort.SliceStable(rank, func(a, b int) bool { return cs[rank[a]] < cs[rank[b]] })
+		m := make([]float64, len(cs))
+		for i, site := range rank {
+			m[site] = targets[i]
+		}
+		return m
+	}
+	misfit := func(x []float64) float64 {
+		cs := couplings(positions(x))
+		f := 0.0
+		for i, t := range matched(cs) {
+			f += math.Pow((cs[i]-t)/scale, 2)
+		}
+		return f
+	}
+	penalty := spacingPenalty
+	objective := func(x []float64) float64 {
+		f := misfit(x)
+		if spacing > 0 {
+			sites := append([]Position{{}}, positions(x)...)
+			for i := range sites {
+				for j := i + 1; j < len(sites); j++ {
+					if d := sites[i].Sub(sites[j]).Norm() / r0; d < spacing {
+						f += penalty * math.Pow((spacing-d)/spacing, 2)
+					}
+				}
+			}
+		}
+		return f
+	}
+	keepsSpacing := func(x []float64) bool {
+		return minSpacing(positions(x)) >= (1-spacingTolerance)*conf.Design.MinSpacing
+	}
+	problem := optimize.Problem{
+		Func: objective,
+		Grad: func(grad, x []float64) {
+			fd.Gradient(grad, objective, x, nil)
+		},
+	}
+	settings := &optimize.Settings{
+		MajorIterations: conf.Design.MaxIterations,
+		Converger:       &optimize.FunctionConverge{Absolute: 1e-14, Iterations: 100},
+	}
+	if settings.MajorIterations == 0 {
+		settings.MajorIterations = 1000
+	}
+
+	restarts := conf.Design.Restarts
+	if restarts < 1 {
+		restarts = 1
+	}
+	best, bestKeeps := math.Inf(1), false
+	var bestX []float64
+	for restart := 0; restart < restarts; restart++ {
+		rng := rand.New(rand.NewSource(conf.Design.Seed + int64(restart)))
+		x := designStart(targets, axis, halfStrength, r0, rng)
+		for penalty = spacingPenalty; ; penalty *= 10 {
+			result, err := optimize.Minimize(problem, x, settings, &optimize.BFGS{})
+			if result == nil {
+				return Design{}, err
+			}
+			x = result.X
+			if keepsSpacing(x) || penalty >= maxSpacingPenalty {
+				break
+			}
+		}
+		keeps := keepsSpacing(x)
+		if f := misfit(x); (keeps && !bestKeeps) || (keeps == bestKeeps && f < best) {
+			best, bestKeeps, bestX = f, keeps, x
+		}
+	}
+
+	d := Design{Positions: positions(bestX)}
+	d.Couplings = couplings(d.Positions)
+	d.Targets = matched(d.Couplings)
+	for i, c := range d.Couplings {
+		d.Residual += math.Pow(c-d.Targets[i], 2) / float64(len(targets))
+	}
+	d.Residual = math.Sqrt(d.Residual) / scale
+	d.MinSpacing = minSpacing(d.Positions)
+	if !bestKeeps {
+		return d, fmt.Errorf("no design keeps the sites %v apart, the closest two are %v apart", conf.Design.MinSpacing, d.MinSpacing)
+	}
+	return d, nil
+}
+
+// minSpacing returns the smallest distance between two of the positions and the central atom at the origin
+func minSpacing(positions []Position) float64 {
+	spacing := math.Inf(1)
+	sites := append([]Position{{}}, positions...)
+	for i := range sites {
+		for j := i + 1; j < len(sites); j++ {
+			spacing = math.Min(spacing, sites[i].Sub(sites[j]).Norm())
+		}
+	}
+	return spacing
+}
+
+// designStart places site i on a random direction with the sign of targets[i], at the distance that reproduces it, in units of r0
+func designStart(targets []float64, axis Position, halfStrength, r0 float64, rng *rand.Rand) []float64 {
+	x := make([]float64, 0, 3*len(targets))
+	for _, t := range targets {
+		u := Position{rng.NormFloat64(), rng.NormFloat64(), rng.NormFloat64()}
+		u = u.Scale(1 / u.Norm())
+		cos := u.Dot(axis)
+		angular := 1 - 3*cos*cos
+		// the perpendicular part of u turns it towards the equator (positive couplings) and the axis part towards the poles (negative)
+		side := u.Sub(axis.Scale(cos))
+		if side.Norm() < 1e-8 {
+			side = perpendicular(axis)
+		}
+		side = side.Scale(1 / side.Norm())
+		pole := axis
+		if cos < 0 {
+			pole = axis.Scale(-1)
+		}
+		switch {
+		case t == 0:
+			u = pole.Scale(1 / math.Sqrt(3)).Add(side.Scale(math.Sqrt(2.0 / 3.0)))
+			angular = 1
+		case t > 0 && angular <= 0:
+			u, angular = side, 1
+		case t < 0 && angular >= 0:
+			u, angular = pole, -2
+		}
+		r := math.Cbrt(halfStrength * math.Abs(angular) / math.Max(math.Abs(t), 1e-300))
+		if t == 0 {
+			r = r0
+		}
+		p := u.Scale(r / r0)
+		x = append(x, p.X, p.Y, p.Z)
+	}
+	return x
+}
@@ -0,0 +1,99 @@
+package cs_q_sim
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestDesignTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    PhysicsConfig
+		want    []float64
+		wantErr bool
+	}{
+		{name: "coefficients", conf: PhysicsConfig{InteractionCoefficients: []float64{0, 1, -2}, Design: DesignConfig{Target: "coefficients"}}, want: []float64{1, -2}},
+		{name: "flat", conf: PhysicsConfig{BathCount: 4, Design: DesignConfig{Target: "flat", Mean: 1, Width: 4}}, want: []float64{-2, 0, 2, 4}},
+		{name: "gaussian median", conf: PhysicsConfig{BathCount: 3, Design: DesignConfig{Target: "gaussian", Mean: 5, Width: 1}}, want: []float64{5 - 0.967421566101701, 5, 5 + 0.967421566101701}},
+		{name: "no bath coefficients", conf: PhysicsConfig{InteractionCoefficients: []float64{0}, Design: DesignConfig{Target: "coefficients"}}, wantErr: true},
+		{name: "unknown target", conf: PhysicsConfig{BathCount: 2, Design: DesignConfig{Target: "lorentzian"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DesignTargets(tt.conf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DesignTargets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("DesignTargets() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("DesignTargets() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDesignGeometry(t *testing.T) {
+	base := PhysicsConfig{Units: "atomic", BathDipoleMoment: 1.77, AtomDipoleMoment: 3626.87}
+	// a bath site at the minimum spacing of 1 on the equator couples with 'closest', the largest positive coupling allowed
+	closest := DipolarCoupling(base, State{Angle: 0, Distance: 1})
+	tests := []struct {
+		name         string
+		design       DesignConfig
+		coeffs       []float64
+		count        int
+		wantResidual float64
+		binding      bool
+	}{
+		{name: "coefficients", design: DesignConfig{Target: "coefficients", MinSpacing: 1, Restarts: 2}, coeffs: []float64{0, 2e5, 1e5, -1e5, -2e5, 0}},
+		{name: "gaussian", design: DesignConfig{Target: "gaussian", Mean: 1e5, Width: 5e4, MinSpacing: 1.5, Seed: 3}, count: 6},
+		// the target needs half the minimum spacing, so the site stops at the spacing and reaches an eighth of it
+		{name: "binding spacing", design: DesignConfig{Target: "coefficients", MinSpacing: 1}, coeffs: []float64{0, 8 * closest}, wantResidual: 7.0 / 8.0, binding: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := base
+			conf.Design, conf.InteractionCoefficients, conf.BathCount = tt.design, tt.coeffs, tt.count
+			d, err := DesignGeometry(conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(d.Residual-tt.wantResidual) > 1e-4 {
+				t.Errorf("Residual = %v, want %v", d.Residual, tt.wantResidual)
+			}
+			if d.MinSpacing < (1-spacingTolerance)*tt.design.MinSpacing {
+				t.Errorf("MinSpacing = %v, want at least %v", d.MinSpacing, tt.design.MinSpacing)
+			}
+			if tt.binding && d.MinSpacing > (1+1e-4)*tt.design.MinSpacing {
+				t.Errorf("MinSpacing = %v, want the bound %v", d.MinSpacing, tt.design.MinSpacing)
+			}
+			// the reported couplings follow from the positions
+			for i, p := range d.Positions {
+				if c := DipolarCoupling(conf, NewState(p, Position{Z: 1})); math.Abs(c-d.Couplings[i]) > 1e-9*math.Abs(c) {
+					t.Errorf("coupling of site %v = %v, reported %v", i, c, d.Couplings[i])
+				}
+			}
+			if tt.design.Target == "coefficients" {
+				for i, c := range tt.coeffs[1:] {
+					if d.Targets[i] != c {
+						t.Errorf("site %v matched with %v, want %v", i, d.Targets[i], c)
+					}
+				}
+			} else {
+				want, _ := DesignTargets(conf)
+				got := append([]float64(nil), d.Targets...)
+				sort.Float64s(got)
+				for i := range want {
+					if got[i] != want[i] {
+						t.Errorf("matched targets = %v, want a permutation of %v", d.Targets, want)
+						break
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package cs_q_sim
+
+import (
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// DegeneracyTolerance returns the distance below which two eigenvalues (or two gaps) are treated as equal,
+// scaled to the largest eigenvalue since the absolute accuracy of the diagonalisation is relative to the norm of the Hamiltonian
+func DegeneracyTolerance(energies []float64) float64 {
+	scale := 1.0
+	for _, e := range energies {
+		scale = math.Max(scale, math.Abs(e))
+	}
+	return 1e-12 * scale
+}
+
+/*
+DiagonalEnsemble returns the infinite-time average of <O(t)> and the temporal variance of its fluctuations around it, given the overlaps c_n from Grammian.
+
+With <O(t)> = Σ_{m,n} c_m c_n O_mn exp(i (E_m - E_n) t) the average keeps the terms with E_m = E_n,
+
+	<O>_DE = Σ_E Σ_{m,n ∈ E} c_m c_n O_mn,
+
+which reduces to Σ_n |c_n|^2 O_nn without degeneracies. The variance is Σ_{ω ≠ 0} |Σ_{E_m - E_n = ω} c_m c_n O_mn|^2,
+so coinciding gaps are summed coherently before squaring. Energies closer than 'tolerance' are treated as degenerate.
+As for Observable.ExpectationValue the real part of <O(t)> is meant, so a non-symmetric O such as S^+ is replaced by (O + O^T) / 2
+*/
+func DiagonalEnsemble(observable *mat.Dense, eigen Eigen, grammian *mat.Dense, tolerance float64) (average, variance float64) {
+	vectors := eigen.EigenVectors
+	var symmetric, oEigen, temp mat.Dense
+	symmetric.Add(observable, observable.T())
+	symmetric.Scale(0.5, &symmetric)
+	temp.Mul(&symmetric, vectors)
+	oEigen.Mul(vectors.T(), &temp)
+
+	type term struct {
+		gap   float64
+		value float64
+	}
+	var terms []term
+	for m, em := range eigen.EigenValues {
+		cm := grammian.At(0, m)
+		if math.Abs(cm) < 1e-12 {
+			continue
+		}
+		for n, en := range eigen.EigenValues {
+			cn := grammian.At(0, n)
+			if math.Abs(cn) < 1e-12 {
+				continue
+			}
+			value := cm * cn * oEigen.At(m, n)
+			if math.Abs(em-en) < tolerance {
+				average += value
+			} else if em > en {
+				// the (n, m) term carries the conjugate phase and the same value, so only positive gaps are kept
+				terms = append(terms, term{em - en, value})
+			}
+		}
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		return terms[i].gap < terms[j].gap
+	})
+	amplitude := 0.0
+	for i, t := range terms {
+		amplitude += t.value
+		if i == len(terms)-1 || terms[i+1].gap-t.gap >= tolerance {
+			variance += 2 * amplitude * amplitude
+			amplitude = 0
+		}
+	}
+	return average, variance
+}
@@ -0,0 +1,79 @@
+package cs_q_sim
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestDiagonalEnsemble(t *testing.T) {
+	type args struct {
+		hamiltonian *mat.SymDense
+		observable  *mat.Dense
+		state       *mat.VecDense
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantAverage  float64
+		wantVariance float64
+	}{
+		{
+			name: "Rabi oscillation",
+			args: args{
+				hamiltonian: mat.NewSymDense(2, []float64{0, 0.7, 0.7, 0}),
+				observable:  mat.NewDense(2, 2, []float64{1, 0, 0, -1}),
+				state:       mat.NewVecDense(2, []float64{1, 0}),
+			},
+			wantAverage:  0.0,
+			wantVariance: 0.5,
+		},
+		{
+			name: "coherences inside a degenerate level",
+			args: args{
+				hamiltonian: mat.NewSymDense(3, []float64{1, 0, 0, 0, 1, 0, 0, 0, 2}),
+				observable:  mat.NewDense(3, 3, []float64{0, 1, 0, 1, 0, 0, 0, 0, 0}),
+				state:       mat.NewVecDense(3, []float64{1 / math.Sqrt2, 1 / math.Sqrt2, 0}),
+			},
+			wantAverage:  1.0,
+			wantVariance: 0.0,
+		},
+		{
+			name: "degenerate gaps add up coherently",
+			args: args{
+				hamiltonian: mat.NewSymDense(3, []float64{0, 0, 0, 0, 1, 0, 0, 0, 2}),
+				observable:  mat.NewDense(3, 3, []float64{0, 1, 0, 1, 0, 1, 0, 1, 0}),
+				state:       mat.NewVecDense(3, []float64{1 / math.Sqrt(3), 1 / math.Sqrt(3), 1 / math.Sqrt(3)}),
+			},
+			// <O(t)> = 4/3 cos(t): both gaps of 1 contribute 2/3 each
+			wantAverage:  0.0,
+			wantVariance: 8.0 / 9.0,
+		},
+		{
+			name: "non-symmetric observable",
+			args: args{
+				hamiltonian: mat.NewSymDense(2, []float64{0, 0, 0, 1}),
+				observable:  Sp(0.5),
+				state:       mat.NewVecDense(2, []float64{1 / math.Sqrt2, 1 / math.Sqrt2}),
+			},
+			// Re <Sp(t)> = cos(t) / 2
+			wantAverage:  0.0,
+			wantVariance: 1.0 / 8.0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &System{}
+			eigen := s.Diagonalize(tt.args.hamiltonian)
+			gram := Grammian(tt.args.state, eigen.EigenVectors)
+			average, variance := DiagonalEnsemble(tt.args.observable, eigen, gram, DegeneracyTolerance(eigen.EigenValues))
+			if math.Abs(average-tt.wantAverage) > 1e-10 {
+				t.Errorf("DiagonalEnsemble() average = %v, want %v", average, tt.wantAverage)
+			}
+			if math.Abs(variance-tt.wantVariance) > 1e-10 {
+				t.Errorf("DiagonalEnsemble() variance = %v, want %v", variance, tt.wantVariance)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package cs_q_sim
+
+import (
+	"math"
+
+	"gonum.org/v1/plot/plotter"
+)
+
+// CurveStatistics returns the pointwise mean, the sample standard deviation and the standard error of the mean of curves sampled on the same grid
+func CurveStatistics(curves []plotter.XYs) (mean, std, stderr plotter.XYs) {
+	if len(curves) == 0 {
+		return nil, nil, nil
+	}
+	n := float64(len(curves))
+	points := len(curves[0])
+	mean = make(plotter.XYs, points)
+	std = make(plotter.XYs, points)
+	stderr = make(plotter.XYs, points)
+	for i := 0; i < points; i++ {
+		sum := 0.0
+		for _, curve := range curves {
+			if len(curve) != points {
+				panic("the curves of an ensemble should share their grid")
+			}
+			sum += curve[i].Y
+		}
+		m := sum / n
+		variance := 0.0
+		for _, curve := range curves {
+			variance += math.Pow(curve[i].Y-m, 2)
+		}
+		if n > 1 {
+			variance /= n - 1
+		}
+		x := curves[0][i].X
+		mean[i] = plotter.XY{X: x, Y: m}
+		std[i] = plotter.XY{X: x, Y: math.Sqrt(variance)}
+		stderr[i] = plotter.XY{X: x, Y: math.Sqrt(variance / n)}
+	}
+	return mean, std, stderr
+}
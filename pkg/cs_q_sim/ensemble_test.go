@@ -0,0 +1,23 @@
+package cs_q_sim
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/plot/plotter"
+)
+
+func TestCurveStatistics(t *testing.T) {
+	curves := []plotter.XYs{
+		{{X: 0, Y: 1}, {X: 1, Y: 2}},
+		{{X: 0, Y: 3}, {X: 1, Y: 2}},
+		{{X: 0, Y: 5}, {X: 1, Y: 2}},
+	}
+	mean, std, stderr := CurveStatistics(curves)
+	want := []struct{ mean, std, stderr float64 }{{3, 2, 2 / math.Sqrt(3)}, {2, 0, 0}}
+	for i, w := range want {
+		if mean[i].X != curves[0][i].X || math.Abs(mean[i].Y-w.mean) > 1e-12 || math.Abs(std[i].Y-w.std) > 1e-12 || math.Abs(stderr[i].Y-w.stderr) > 1e-12 {
+			t.Errorf("CurveStatistics()[%v] = %v, %v, %v, want %+v", i, mean[i], std[i], stderr[i], w)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+package cs_q_sim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+/*
+ParseOperator builds the many-body operator described by an algebraic expression over site operators, e.g.
+
+	Sz0*Sz3 + 0.5*(Sp1*Sm2 + Sm1*Sp2)
+	sum_j Sz_j
+	Sz0 - mean(Sz_bath)
+
+Site operators are Sz, Sx, Sp, Sm and Id followed by a slot, written as 3 or _3. The slot may also be a summation variable
+bound by sum_j or mean_j, which run over all slots and apply to the rest of the product that follows them, or one of the groups
+'bath' (slots 1, ..., sites-1) and 'all' used inside sum(...) or mean(...). Products are operator products, so their order matters
+*/
+func ParseOperator(expression string, spin float64, sites int) (*mat.Dense, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, spin: spin, sites: sites}
+	root, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in %q", p.tokens[p.pos], expression)
+	}
+	value, err := root(map[string]int{})
+	if err != nil {
+		return nil, err
+	}
+	return value.dense(int(2*spin+1), sites), nil
+}
+
+// term is a scalar multiple of the identity when op is nil
+type term struct {
+	scalar float64
+	op     *mat.Dense
+}
+
+func (t term) dense(localDim, sites int) *mat.Dense {
+	if t.op != nil {
+		return t.op
+	}
+	dim := 1
+	for i := 0; i < sites; i++ {
+		dim *= localDim
+	}
+	d := mat.NewDense(dim, dim, nil)
+	for i := 0; i < dim; i++ {
+		d.Set(i, i, t.scalar)
+	}
+	return d
+}
+
+// node evaluates a parsed subexpression given the values of the bound slot variables
+type node func(env map[string]int) (term, error)
+
+type parser struct {
+	tokens []string
+	pos    int
+	spin   float64
+	sites  int
+	groups map[string]bool // groups referenced since the innermost sum(...) or mean(...) started
+}
+
+func tokenize(expression string) ([]string, error) {
+	var tokens []string
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("+-*/()", r):
+			tokens = append(tokens, string(r))
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.' ||
+				((runes[j] == 'e' || runes[j] == 'E') && j+1 < len(runes) && (unicode.IsDigit(runes[j+1]) || runes[j+1] == '-' || runes[j+1] == '+')) ||
+				((runes[j] == '-' || runes[j] == '+') && (runes[j-1] == 'e' || runes[j-1] == 'E'))) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		case unicode.IsLetter(r):
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q in %q", r, expression)
+		}
+	}
+	return tokens, nil
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) expect(token string) error {
+	if p.peek() != token {
+		return fmt.Errorf("expected %q, got %q", token, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+// expression = product { ("+" | "-") product }
+func (p *parser) expression() (node, error) {
+	left, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "+" || p.peek() == "-" {
+		sign := 1.0
+		if p.tokens[p.pos] == "-" {
+			sign = -1.0
+		}
+		p.pos++
+		right, err := p.product()
+		if err != nil {
+			return nil, err
+		}
+		left = p.add(left, right, sign)
+	}
+	return left, nil
+}
+
+// product = factor { ("*" | "/") factor }, where only scalars may divide
+func (p *parser) product() (node, error) {
+	left, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "*" || p.peek() == "/" {
+		divide := p.tokens[p.pos] == "/"
+		p.pos++
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		left = multiply(left, right, divide)
+	}
+	return left, nil
+}
+
+// factor = ["+" | "-"] factor | number | "(" expression ")" | site operator | sum_j product | mean_j product | (sum | mean) "(" expression ")"
+func (p *parser) factor() (node, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case token == "-" || token == "+":
+		p.pos++
+		inner, err := p.factor()
+		if err != nil || token == "+" {
+			return inner, err
+		}
+		return multiply(constant(-1), inner, false), nil
+	case token == "(":
+		p.pos++
+		inner, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	case unicode.IsDigit(rune(token[0])) || token[0] == '.':
+		value, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, err
+		}
+		p.pos++
+		return constant(value), nil
+	case token == "sum" || token == "mean":
+		p.pos++
+		return p.groupReduction(token == "mean")
+	case strings.HasPrefix(token, "sum_") || strings.HasPrefix(token, "mean_"):
+		p.pos++
+		name, variable, _ := strings.Cut(token, "_")
+		return p.variableReduction(variable, name == "mean")
+	default:
+		p.pos++
+		return p.siteOperator(token)
+	}
+}
+
+// groupReduction sums or averages its argument over the slots of the single group it refers to
+func (p *parser) groupReduction(mean bool) (node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	outer := p.groups
+	p.groups = map[string]bool{}
+	inner, err := p.expression()
+	groups := p.groups
+	p.groups = outer
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if len(groups) != 1 {
+		return nil, fmt.Errorf("sum(...) and mean(...) should refer to exactly one of the groups 'bath' or 'all'")
+	}
+	for group := range groups {
+		first := 0
+		if group == "bath" {
+			first = 1
+		}
+		return p.reduce(inner, group, first, mean), nil
+	}
+	return nil, nil
+}
+
+// variableReduction sums or averages the rest of the product over all slots bound to the variable
+func (p *parser) variableReduction(variable string, mean bool) (node, error) {
+	if variable == "" || variable == "bath" || variable == "all" {
+		return nil, fmt.Errorf("invalid summation variable %q", variable)
+	}
+	inner, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+	return p.reduce(inner, variable, 0, mean), nil
+}
+
+func (p *parser) reduce(inner node, variable string, first int, mean bool) node {
+	return func(env map[string]int) (term, error) {
+		outer, bound := env[variable]
+		defer func() {
+			if bound {
+				env[variable] = outer
+			} else {
+				delete(env, variable)
+			}
+		}()
+		total := term{}
+		for j := first; j < p.sites; j++ {
+			env[variable] = j
+			value, err := inner(env)
+			if err != nil {
+				return term{}, err
+			}
+			total = p.sum(total, value, 1.0)
+		}
+		if mean && p.sites > first {
+			return scale(total, 1/float64(p.sites-first)), nil
+		}
+		return total, nil
+	}
+}
+
+// siteOperator parses tokens such as Sz3, Sz_3, Sz_j or Sz_bath
+func (p *parser) siteOperator(token string) (node, error) {
+	if len(token) < 3 {
+		return nil, fmt.Errorf("unknown operator %q", token)
+	}
+	var local *mat.Dense
+	switch token[:2] {
+	case "Sz":
+		local = Sz(p.spin)
+	case "Sp":
+		local = Sp(p.spin)
+	case "Sm":
+		local = Sm(p.spin)
+	case "Sx":
+		local = mat.DenseCopyOf(Sp(p.spin))
+		local.Add(local, Sm(p.spin))
+		local.Scale(0.5, local)
+	case "Id":
+		local = Id(p.spin)
+	default:
+		return nil, fmt.Errorf("unknown operator %q", token)
+	}
+	index := strings.TrimPrefix(token[2:], "_")
+	if slot, err := strconv.Atoi(index); err == nil {
+		if slot < 0 || slot >= p.sites {
+			return nil, fmt.Errorf("slot %v of %q is out of range for %v sites", slot, token, p.sites)
+		}
+		op := ManyBodyOperator(local, slot, p.sites)
+		return func(map[string]int) (term, error) { return term{op: op}, nil }, nil
+	}
+	if index == "bath" || index == "all" {
+		if p.groups == nil {
+			return nil, fmt.Errorf("%q is only allowed inside sum(...) or mean(...)", token)
+		}
+		p.groups[index] = true
+	}
+	return func(env map[string]int) (term, error) {
+		slot, ok := env[index]
+		if !ok {
+			return term{}, fmt.Errorf("unbound slot variable %q in %q", index, token)
+		}
+		return term{op: ManyBodyOperator(local, slot, p.sites)}, nil
+	}, nil
+}
+
+func constant(value float64) node {
+	return func(map[string]int) (term, error) { return term{scalar: value}, nil }
+}
+
+func scale(t term, factor float64) term {
+	if t.op == nil {
+		return term{scalar: t.scalar * factor}
+	}
+	var scaled mat.Dense
+	scaled.Scale(factor, t.op)
+	return term{op: &scaled}
+}
+
+// sum returns a + sign*b
+func (p *parser) sum(a, b term, sign float64) term {
+	if a.op == nil && b.op == nil {
+		return term{scalar: a.scalar + sign*b.scalar}
+	}
+	localDim := int(2*p.spin + 1)
+	var s mat.Dense
+	s.Scale(sign, b.dense(localDim, p.sites))
+	s.Add(&s, a.dense(localDim, p.sites))
+	return term{op: &s}
+}
+
+func (p *parser) add(left, right node, sign float64) node {
+	return func(env map[string]int) (term, error) {
+		a, err := left(env)
+		if err != nil {
+			return term{}, err
+		}
+		b, err := right(env)
+		if err != nil {
+			return term{}, err
+		}
+		return p.sum(a, b, sign), nil
+	}
+}
+
+func multiply(left, right node, divide bool) node {
+	return func(env map[string]int) (term, error) {
+		a, err := left(env)
+		if err != nil {
+			return term{}, err
+		}
+		b, err := right(env)
+		if err != nil {
+			return term{}, err
+		}
+		if divide {
+			if b.op != nil {
+				return term{}, fmt.Errorf("cannot divide by an operator")
+			}
+			return scale(a, 1/b.scalar), nil
+		}
+		switch {
+		case a.op == nil:
+			return scale(b, a.scalar), nil
+		case b.op == nil:
+			return scale(a, b.scalar), nil
+		}
+		var m mat.Dense
+		m.Mul(a.op, b.op)
+		return term{op: &m}, nil
+	}
+}
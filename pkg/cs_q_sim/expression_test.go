@@ -0,0 +1,97 @@
+package cs_q_sim
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestParseOperator(t *testing.T) {
+	sz := func(slot int) *mat.Dense { return ManyBodyOperator(Sz(0.5), slot, 3) }
+	sp := func(slot int) *mat.Dense { return ManyBodyOperator(Sp(0.5), slot, 3) }
+	sm := func(slot int) *mat.Dense { return ManyBodyOperator(Sm(0.5), slot, 3) }
+	product := func(a, b *mat.Dense) *mat.Dense {
+		var m mat.Dense
+		m.Mul(a, b)
+		return &m
+	}
+	sum := func(weights []float64, ms ...*mat.Dense) *mat.Dense {
+		s := mat.NewDense(8, 8, nil)
+		for i, m := range ms {
+			var scaled mat.Dense
+			scaled.Scale(weights[i], m)
+			s.Add(s, &scaled)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		want       *mat.Dense
+	}{
+		{
+			name:       "single site",
+			expression: "Sz_2",
+			want:       sz(2),
+		},
+		{
+			name:       "flip-flop",
+			expression: "Sz0*Sz2 + 0.5*(Sp1*Sm2 + Sm1*Sp2)",
+			want:       sum([]float64{1, 0.5, 0.5}, product(sz(0), sz(2)), product(sp(1), sm(2)), product(sm(1), sp(2))),
+		},
+		{
+			name:       "total magnetisation",
+			expression: "sum_j Sz_j",
+			want:       sum([]float64{1, 1, 1}, sz(0), sz(1), sz(2)),
+		},
+		{
+			name:       "central spin against the mean bath",
+			expression: "Sz0 - mean(Sz_bath)",
+			want:       sum([]float64{1, -0.5, -0.5}, sz(0), sz(1), sz(2)),
+		},
+		{
+			name:       "scalars and unary minus",
+			expression: "-2*Sx1/4 + 1e-1",
+			want: sum([]float64{-0.25, -0.25, 0.1},
+				sp(1), sm(1), ManyBodyOperator(Id(0.5), 0, 3)),
+		},
+		{
+			name:       "nested sums",
+			expression: "sum_i sum_j Sz_i*Sz_j",
+			want: func() *mat.Dense {
+				total := sum([]float64{1, 1, 1}, sz(0), sz(1), sz(2))
+				return product(total, total)
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOperator(tt.expression, 0.5, 3)
+			if err != nil {
+				t.Fatalf("ParseOperator() error = %v", err)
+			}
+			if !mat.EqualApprox(got, tt.want, 1e-12) {
+				t.Errorf("ParseOperator() = %v, want %v", mat.Formatted(got), mat.Formatted(tt.want))
+			}
+		})
+	}
+}
+
+func TestParseOperator_Errors(t *testing.T) {
+	for _, expression := range []string{
+		"Sz3",
+		"Sz_j",
+		"Sy0",
+		"Sz0 +",
+		"(Sz0",
+		"Sz_bath",
+		"mean(Sz0)",
+		"1/Sz0",
+		"Sz0 # Sz1",
+	} {
+		if _, err := ParseOperator(expression, 0.5, 3); err == nil {
+			t.Errorf("ParseOperator(%q) should fail", expression)
+		}
+	}
+}
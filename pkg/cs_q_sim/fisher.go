@@ -0,0 +1,50 @@
+package cs_q_sim
+
+import (
+	"math"
+	"math/cmplx"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+/*
+QuantumFisherInformation returns the QFI of the pure state |ψ(t)> = exp(-iHt)|ψ(0)> with respect to a parameter λ of the Hamiltonian, given dH = ∂H/∂λ.
+
+Since ∂_λ|ψ(t)> = -i exp(-iHt) G |ψ(0)> with the generator G = ∫_0^t exp(iHs) ∂H exp(-iHs) ds, the QFI is F_Q = 4 Var_ψ(0)(G).
+In the eigenbasis G_mn = ∂H_mn (exp(i(E_m - E_n)t) - 1) / (i(E_m - E_n)), and G_mn = t ∂H_mn within degenerate levels.
+The overlaps c_n = <E_n|ψ(0)> are given by Grammian, and energies closer than 'tolerance' are treated as degenerate
+*/
+func QuantumFisherInformation(dH *mat.Dense, eigen Eigen, grammian *mat.Dense, time, tolerance float64) float64 {
+	vectors := eigen.EigenVectors
+	var dHEigen, temp mat.Dense
+	temp.Mul(dH, vectors)
+	dHEigen.Mul(vectors.T(), &temp)
+
+	dim := len(eigen.EigenValues)
+	// g = G c
+	g := make([]complex128, dim)
+	for m, em := range eigen.EigenValues {
+		for n, en := range eigen.EigenValues {
+			cn := grammian.At(0, n)
+			if cn == 0 {
+				continue
+			}
+			omega := em - en
+			var factor complex128
+			if math.Abs(omega) < tolerance {
+				factor = complex(time, 0)
+			} else {
+				factor = (cmplx.Exp(complex(0, omega*time)) - 1) / complex(0, omega)
+			}
+			g[m] += complex(dHEigen.At(m, n)*cn, 0) * factor
+		}
+	}
+
+	var mean complex128
+	second := 0.0
+	for m := range g {
+		mean += complex(grammian.At(0, m), 0) * g[m]
+		second += math.Pow(cmplx.Abs(g[m]), 2)
+	}
+	return 4 * (second - math.Pow(real(mean), 2))
+}
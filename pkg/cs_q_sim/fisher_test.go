@@ -0,0 +1,67 @@
+package cs_q_sim
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestQuantumFisherInformation_Ramsey(t *testing.T) {
+	// a single spin precessing in B Sz from |+> has F_Q = t^2 with respect to B
+	s := &System{}
+	eigen := s.Diagonalize(mat.NewSymDense(2, []float64{0.35, 0, 0, -0.35}))
+	gram := Grammian(mat.NewVecDense(2, ManyBodyVector("p", 2)), eigen.EigenVectors)
+	for _, time := range []float64{0.0, 1.0, 2.5} {
+		if got := QuantumFisherInformation(Sz(0.5), eigen, gram, time, 1e-12); math.Abs(got-time*time) > 1e-10 {
+			t.Errorf("QuantumFisherInformation(t = %v) = %v, want %v", time, got, time*time)
+		}
+	}
+}
+
+func TestQuantumFisherInformation_SuperposedKet(t *testing.T) {
+	// p spans two magnetisation sectors, so the ket stays in the full space and the central spin precesses as in a Ramsey experiment
+	s := &System{
+		Bath:          []State{{}, {}},
+		PhysicsConfig: PhysicsConfig{Spin: 0.5, InitialKet: "pdu", BathCount: 2, InteractionCoefficients: []float64{0.0, 0.0, 0.0}},
+	}
+	s.DownSpins = DownSpinCount(s.PhysicsConfig.InitialKet)
+	ket := s.InitialKet()
+	eigen := s.Diagonalize(s.Hamiltonian(0.7, 0.4))
+	gram := Grammian(ket, eigen.EigenVectors)
+	dH := ManyBodyOperator(Sz(0.5), 0, 3)
+	for _, time := range []float64{0.0, 1.0, 2.5} {
+		if got := QuantumFisherInformation(dH, eigen, gram, time, DegeneracyTolerance(eigen.EigenValues)); math.Abs(got-time*time) > 1e-10 {
+			t.Errorf("QuantumFisherInformation(t = %v) = %v, want %v", time, got, time*time)
+		}
+	}
+}
+
+func TestQuantumFisherInformation_FiniteDifferences(t *testing.T) {
+	s := &System{
+		Bath:          []State{{}, {}},
+		PhysicsConfig: PhysicsConfig{Spin: 0.5, Model: "XXX", InteractionCoefficients: []float64{0.0, 1.0, -0.4}},
+	}
+	b0, b, delta := 1.2, 0.9, 1e-5
+	ket := mat.NewVecDense(8, ManyBodyVector("pud", 2))
+	eigen := s.Diagonalize(s.Hamiltonian(b0, b))
+	plus := s.Diagonalize(s.Hamiltonian(b0+delta, b))
+	minus := s.Diagonalize(s.Hamiltonian(b0-delta, b))
+
+	for _, time := range []float64{0.5, 3.0} {
+		psi := Evolve(ket, time, eigen.EigenValues, eigen.EigenVectors, Grammian(ket, eigen.EigenVectors))
+		psiPlus := Evolve(ket, time, plus.EigenValues, plus.EigenVectors, Grammian(ket, plus.EigenVectors))
+		psiMinus := Evolve(ket, time, minus.EigenValues, minus.EigenVectors, Grammian(ket, minus.EigenVectors))
+		derivative := make([]complex128, len(psi))
+		for i := range psi {
+			derivative[i] = (psiPlus[i] - psiMinus[i]) / complex(2*delta, 0)
+		}
+		want := 4 * (real(InnerProduct(derivative, derivative)) - math.Pow(cmplx.Abs(InnerProduct(psi, derivative)), 2))
+
+		got := QuantumFisherInformation(ManyBodyOperator(Sz(0.5), 0, 3), eigen, Grammian(ket, eigen.EigenVectors), time, DegeneracyTolerance(eigen.EigenValues))
+		if math.Abs(got-want) > 1e-5*math.Max(1, want) {
+			t.Errorf("QuantumFisherInformation(t = %v) = %v, want %v", time, got, want)
+		}
+	}
+}
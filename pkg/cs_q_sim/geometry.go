@@ -0,0 +1,210 @@
+package cs_q_sim
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+/*
+Geometry places the bath around the central atom. Positions are given before the tilt and the orientation, which enter through QuantisationAxis.
+
+SiteCount declares how many bath sites the geometry holds for the configuration, or 0 if it adapts to any BathCount.
+Positions returns the BathCount positions relative to the central atom, in the distance unit (see DistanceUnit)
+*/
+type Geometry interface {
+	SiteCount(conf PhysicsConfig) (int, error)
+	Positions(conf PhysicsConfig) ([]Position, error)
+}
+
+var geometries = map[string]Geometry{
+	"ring":                    ringGeometry{},
+	"sphere":                  sphereGeometry{},
+	"cube":                    VertexGeometry(cubeVertices()),
+	"dodecahedron":            VertexGeometry(dodecahedronVertices()),
+	"icosahedron":             VertexGeometry(icosahedronVertices()),
+	"tetrahedron":             VertexGeometry(tetrahedronVertices()),
+	"octahedron":              VertexGeometry(octahedronVertices()),
+	"truncated-tetrahedron":   VertexGeometry(truncatedTetrahedronVertices()),
+	"cuboctahedron":           VertexGeometry(cuboctahedronVertices()),
+	"truncated-octahedron":    VertexGeometry(truncatedOctahedronVertices()),
+	"truncated-cube":          VertexGeometry(truncatedCubeVertices()),
+	"rhombicuboctahedron":     VertexGeometry(rhombicuboctahedronVertices()),
+	"snub-cube":               VertexGeometry(snubCubeVertices()),
+	"truncated-cuboctahedron": VertexGeometry(truncatedCuboctahedronVertices()),
+	"icosidodecahedron":       VertexGeometry(icosidodecahedronVertices()),
+	"truncated-icosahedron":   VertexGeometry(truncatedIcosahedronVertices()),
+	"truncated-dodecahedron":  VertexGeometry(truncatedDodecahedronVertices()),
+	"rhombicosidodecahedron":  VertexGeometry(rhombicosidodecahedronVertices()),
+	"snub-dodecahedron":       VertexGeometry(snubDodecahedronVertices()),
+	"platonic":                platonicGeometry{},
+	"cylinder":                cylinderGeometry{},
+	"double-ring":             doubleRingGeometry{},
+	"file":                    fileGeometry{},
+	"random":                  randomGeometry{},
+	"square":                  latticeGeometry("square"),
+	"triangular":              latticeGeometry("triangular"),
+	"cubic":                   latticeGeometry("cubic"),
+}
+
+// RegisterGeometry makes a geometry selectable by name in the config
+func RegisterGeometry(name string, g Geometry) {
+	if _, ok := geometries[name]; ok {
+		panic("geometry " + name + " is already registered")
+	}
+	geometries[name] = g
+}
+
+// GeometryNames returns the names of the registered geometries in alphabetical order
+func GeometryNames() []string {
+	names := make([]string, 0, len(geometries))
+	for name := range geometries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GeometryPositions returns the bath positions of conf.Geometry, failing if the geometry is unknown or cannot hold BathCount sites
+func GeometryPositions(conf PhysicsConfig) ([]Position, error) {
+	g, ok := geometries[conf.Geometry]
+	if !ok {
+		return nil, fmt.Errorf("unknown geometry %q, expected one of %v", conf.Geometry, GeometryNames())
+	}
+	count, err := g.SiteCount(conf)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 && count != conf.BathCount {
+		return nil, fmt.Errorf("the %v geometry holds %v bath sites, but the bath count is %v", conf.Geometry, count, conf.BathCount)
+	}
+	positions, err := g.Positions(conf)
+	if err != nil {
+		return nil, err
+	}
+	if len(positions) != conf.BathCount {
+		return nil, fmt.Errorf("the %v geometry placed %v bath sites, but the bath count is %v", conf.Geometry, len(positions), conf.BathCount)
+	}
+	for i, p := range positions {
+		if p.Norm() == 0 {
+			return nil, fmt.Errorf("bath site %v of the %v geometry coincides with the central atom", i, conf.Geometry)
+		}
+	}
+	return positions, nil
+}
+
+type vertexGeometry []Position
+
+// VertexGeometry places the bath at ConstantDistance along the given unit vectors, one site per vertex
+func VertexGeometry(vertices []Position) Geometry {
+	return vertexGeometry(vertices)
+}
+
+func (v vertexGeometry) SiteCount(PhysicsConfig) (int, error) {
+	return len(v), nil
+}
+
+func (v vertexGeometry) Positions(conf PhysicsConfig) ([]Position, error) {
+	positions := make([]Position, len(v))
+	for i, vertex := range v {
+		positions[i] = vertex.Scale(conf.ConstantDistance)
+	}
+	return positions, nil
+}
+
+func cubeVertices() []Position {
+	a := 1 / math.Sqrt(3.0)
+	return []Position{{a, a, a}, {-a, a, a}, {a, -a, a}, {-a, -a, a}, {a, a, -a}, {-a, a, -a}, {a, -a, -a}, {-a, -a, -a}}
+}
+
+func dodecahedronVertices() []Position {
+	a := 1 / math.Sqrt(3.0)
+	phi := golden * a
+	iphi := a / golden
+	return []Position{{a, a, a}, {-a, a, a}, {a, -a, a}, {-a, -a, a},
+		{a, a, -a}, {-a, a, -a}, {a, -a, -a}, {-a, -a, -a},
+		{0.0, phi, iphi}, {0.0, -phi, iphi}, {0.0, phi, -iphi}, {0.0, -phi, -iphi},
+		{iphi, 0.0, phi}, {-iphi, 0.0, phi}, {iphi, 0.0, -phi}, {-iphi, 0.0, -phi},
+		{phi, iphi, 0.0}, {-phi, iphi, 0.0}, {phi, -iphi, 0.0}, {-phi, -iphi, 0.0}}
+}
+
+func icosahedronVertices() []Position {
+	/*
+		vertices calculated with mathematica
+		https://www.wolframcloud.com/obj/76badea4-ada5-4dc5-a415-8d6ea89de353
+	*/
+	return []Position{{0.0, 0.0, -1.0}, {0.0, 0.0, 1.0}, {-0.894427, 0.0, -0.447214}, {0.894427, 0.0, 0.447214}, {0.723607, -0.525731, -0.447214}, {0.723607, 0.525731, -0.447214}, {-0.723607, -0.525731, 0.447214}, {-0.723607, 0.525731, 0.447214}, {-0.276393, -0.850651, -0.447214}, {-0.276393, 0.850651, -0.447214}, {0.276393, -0.850651, 0.447214}, {0.276393, 0.850651, 0.447214}}
+}
+
+// ringGeometry spaces the bath evenly on a circle in the xy plane
+type ringGeometry struct{}
+
+func (ringGeometry) SiteCount(PhysicsConfig) (int, error) {
+	return 0, nil
+}
+
+func (ringGeometry) Positions(conf PhysicsConfig) ([]Position, error) {
+	return ringPositions(conf.BathCount, conf.ConstantDistance, false), nil
+}
+
+// sphereGeometry spreads the bath over a sphere, https://stackoverflow.com/questions/9600801/evenly-distributing-n-points-on-a-sphere
+type sphereGeometry struct{}
+
+func (sphereGeometry) SiteCount(PhysicsConfig) (int, error) {
+	return 0, nil
+}
+
+func (sphereGeometry) Positions(conf PhysicsConfig) ([]Position, error) {
+	bc := float64(conf.BathCount)
+	positions := make([]Position, conf.BathCount)
+	for j := range positions {
+		phi := math.Acos(1.0 - 2.0*(float64(j)+0.5)/bc)
+		theta := math.Pi * (1.0 + math.Sqrt(5.0)) * bc
+		positions[j] = Position{math.Cos(theta) * math.Sin(phi), math.Sin(theta) * math.Sin(phi), math.Cos(phi)}.Scale(conf.ConstantDistance)
+	}
+	return positions, nil
+}
+
+// fileGeometry reads the bath from PositionsFile, see ReadPositions
+type fileGeometry struct{}
+
+func (fileGeometry) SiteCount(conf PhysicsConfig) (int, error) {
+	positions, err := fileGeometry{}.Positions(conf)
+	return len(positions), err
+}
+
+func (fileGeometry) Positions(conf PhysicsConfig) ([]Position, error) {
+	return ReadPositions(conf.PositionsFile, conf.PositionUnits, DistanceUnit(conf))
+}
+
+// randomGeometry draws the bath according to conf.Disorder, see RandomPositions
+type randomGeometry struct{}
+
+func (randomGeometry) SiteCount(PhysicsConfig) (int, error) {
+	return 0, nil
+}
+
+func (randomGeometry) Positions(conf PhysicsConfig) ([]Position, error) {
+	return RandomPositions(conf.BathCount, conf.Disorder, rand.New(rand.NewSource(conf.Disorder.Seed)))
+}
+
+// latticeGeometry occupies the sites of a lattice according to conf.Lattice, see LatticePositions
+type latticeGeometry string
+
+func (l latticeGeometry) SiteCount(conf PhysicsConfig) (int, error) {
+	if len(conf.Lattice.Occupation) == 0 {
+		return 0, nil
+	}
+	count := 0
+	for _, occupied := range conf.Lattice.Occupation {
+		if occupied != 0 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (l latticeGeometry) Positions(conf PhysicsConfig) ([]Position, error) {
+	return LatticePositions(string(l), conf.BathCount, conf.Lattice)
+}
@@ -0,0 +1,94 @@
+package cs_q_sim
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+
+	"gonum.org/v1/plot/palette"
+	"gonum.org/v1/plot/palette/moreland"
+)
+
+// CouplingColorMap maps couplings onto a diverging blue-red scale, symmetric about zero and spanning the largest coupling of the bath
+func CouplingColorMap(s *System) palette.ColorMap {
+	largest := 0.0
+	for _, state := range s.Bath {
+		largest = math.Max(largest, math.Abs(state.InteractionStrength))
+	}
+	if largest == 0 {
+		largest = 1
+	}
+	cm := moreland.SmoothBlueRed()
+	cm.SetMin(-largest)
+	cm.SetMax(largest)
+	return cm
+}
+
+func couplingColor(cm palette.ColorMap, coupling float64) color.Color {
+	c, err := cm.At(coupling)
+	if err != nil {
+		return color.Black
+	}
+	return c
+}
+
+// checkGeometry reports an error if the system holds no bath sites away from the central atom
+func checkGeometry(positions []Position) error {
+	for _, p := range positions {
+		if p.Norm() > 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("the system holds no bath positions, e.g. it was set up by interaction coefficients alone")
+}
+
+// WriteXYZ writes the central atom (labelled central) and the bath sites (labelled B) in the XYZ format read by ReadPositions,
+// with coordinates in 'unit' and the couplings in the comment line
+func WriteXYZ(w io.Writer, s *System, unit string) error {
+	positions := s.SitePositions()
+	if err := checkGeometry(positions); err != nil {
+		return err
+	}
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "%d\n", len(positions)+1)
+	fmt.Fprintf(b, "units=%s couplings=", unit)
+	for i, state := range s.Bath {
+		if i > 0 {
+			fmt.Fprint(b, ",")
+		}
+		fmt.Fprintf(b, "%g", state.InteractionStrength)
+	}
+	fmt.Fprintln(b)
+	fmt.Fprintf(b, "central %g %g %g\n", 0.0, 0.0, 0.0)
+	for _, p := range positions {
+		fmt.Fprintf(b, "B %g %g %g\n", p.X, p.Y, p.Z)
+	}
+	return b.Flush()
+}
+
+// WriteOBJ writes the central atom and the bath sites as a point cloud in the Wavefront OBJ format, with coordinates in 'unit' and
+// vertex colours (r g b after the coordinates) following CouplingColorMap. The central atom is the first vertex and is drawn black
+func WriteOBJ(w io.Writer, s *System, unit string) error {
+	positions := s.SitePositions()
+	if err := checkGeometry(positions); err != nil {
+		return err
+	}
+	cm := CouplingColorMap(s)
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "# central spin and %d bath sites, coordinates in %s\n", len(positions), unit)
+	fmt.Fprintf(b, "o central\nv %g %g %g 0 0 0\n", 0.0, 0.0, 0.0)
+	fmt.Fprintln(b, "o bath")
+	for i, p := range positions {
+		r, g, bl, _ := couplingColor(cm, s.Bath[i].InteractionStrength).RGBA()
+		fmt.Fprintf(b, "# coupling %g\n", s.Bath[i].InteractionStrength)
+		fmt.Fprintf(b, "v %g %g %g %.4f %.4f %.4f\n", p.X, p.Y, p.Z, float64(r)/0xffff, float64(g)/0xffff, float64(bl)/0xffff)
+	}
+	fmt.Fprint(b, "p")
+	for i := 1; i <= len(positions)+1; i++ {
+		fmt.Fprintf(b, " %d", i)
+	}
+	fmt.Fprintln(b)
+	return b.Flush()
+}
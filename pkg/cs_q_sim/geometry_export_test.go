@@ -0,0 +1,92 @@
+package cs_q_sim
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func exportedSystem() *System {
+	return &System{Bath: []State{
+		{Angle: 1, Distance: 2, InteractionStrength: -4, Position: Position{Z: 2}},
+		{Angle: 0, Distance: 1, InteractionStrength: 2, Position: Position{X: 1}},
+	}}
+}
+
+func TestWriteXYZ(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteXYZ(&buf, exportedSystem(), "um"); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "bath.xyz")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// the file geometry reads the export back, leaving out the central atom
+	got, err := ReadPositions(path, "", "nm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Position{{Z: 2000}, {X: 1000}}
+	if len(got) != len(want) {
+		t.Fatalf("ReadPositions() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].Sub(want[i]).Norm() > 1e-9 {
+			t.Errorf("ReadPositions() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestWriteOBJ(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteOBJ(&buf, exportedSystem(), "um"); err != nil {
+		t.Fatal(err)
+	}
+	var vertices []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "v ") {
+			vertices = append(vertices, line)
+		}
+	}
+	if len(vertices) != 3 || !strings.Contains(buf.String(), "\np 1 2 3\n") {
+		t.Fatalf("WriteOBJ() =\n%v", buf.String())
+	}
+	// the strongest negative coupling takes the blue end of the colour map
+	fields := strings.Fields(vertices[1])
+	if len(fields) != 7 {
+		t.Fatalf("vertex %q should read 'v x y z r g b'", vertices[1])
+	}
+	red, _ := strconv.ParseFloat(fields[4], 64)
+	blue, _ := strconv.ParseFloat(fields[6], 64)
+	if red >= blue {
+		t.Errorf("vertex %q should be blue", vertices[1])
+	}
+}
+
+func TestWriteGeometry_NoPositions(t *testing.T) {
+	s := &System{Bath: make([]State, 3)}
+	var buf bytes.Buffer
+	if err := WriteXYZ(&buf, s, "um"); err == nil {
+		t.Errorf("WriteXYZ() should fail without positions")
+	}
+	if err := WriteOBJ(&buf, s, "um"); err == nil {
+		t.Errorf("WriteOBJ() should fail without positions")
+	}
+}
+
+func TestSystem_SitePositions(t *testing.T) {
+	// a site known by distance and angle only lies in the plane of the axis and perpendicular(axis)
+	s := &System{Bath: []State{{Angle: 0.5, Distance: 2}, {Position: Position{Y: 3}}}}
+	got := s.SitePositions()
+	if math.Abs(got[0].Norm()-2) > 1e-12 || math.Abs(got[0].Z-1) > 1e-12 {
+		t.Errorf("SitePositions()[0] = %v, want distance 2 at cos 0.5 from z", got[0])
+	}
+	if got[1] != (Position{Y: 3}) {
+		t.Errorf("SitePositions()[1] = %v, want the stored position", got[1])
+	}
+}
@@ -0,0 +1,221 @@
+package cs_q_sim
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// lengthUnits maps the accepted length units onto metres
+var lengthUnits = map[string]float64{
+	"m":          1.0,
+	"mm":         1e-3,
+	"um":         1e-6,
+	"µm":         1e-6,
+	"micrometre": 1e-6,
+	"micrometer": 1e-6,
+	"nm":         1e-9,
+	"angstrom":   1e-10,
+	"a0":         5.29177210903e-11,
+	"bohr":       5.29177210903e-11,
+}
+
+// LengthUnit returns the length of the unit in metres
+func LengthUnit(unit string) (float64, error) {
+	if scale, ok := lengthUnits[strings.ToLower(strings.TrimSpace(unit))]; ok {
+		return scale, nil
+	}
+	return 0, fmt.Errorf("unknown length unit %q", unit)
+}
+
+// DistanceUnit returns the length unit in which InteractionAt expects distances: the declared one of UnitSystem, µm for atomic units and m otherwise
+func DistanceUnit(conf PhysicsConfig) string {
+	if conf.UnitSystem.declared() {
+		return conf.UnitSystem.Distance
+	}
+	if conf.Units == "atomic" {
+		return "um"
+	}
+	return "m"
+}
+
+/*
+ReadPositions reads bath positions from an XYZ (by extension .xyz) or a CSV file and converts them to the 'target' unit.
+
+An XYZ file starts with the number of sites and a comment line, which may state the unit as units=um, followed by lines 'label x y z [unit]'.
+A CSV file has columns x, y, z and optionally label and units, either named in a header row (recognised by its x, y or z column) or in the order [label,] x, y, z[, units].
+Coordinates without a unit are in 'units'. A site labelled 'central' is taken as the origin and is not a part of the bath
+*/
+func ReadPositions(path, units, target string) ([]Position, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rows []positionRow
+	if strings.EqualFold(filepath.Ext(path), ".xyz") {
+		rows, err = readXYZ(f, units)
+	} else {
+		rows, err = readCSV(f, units)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	targetScale, err := LengthUnit(target)
+	if err != nil {
+		return nil, err
+	}
+	var origin Position
+	var positions []Position
+	for _, row := range rows {
+		scale, err := LengthUnit(row.unit)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", path, err)
+		}
+		scale /= targetScale
+		p := Position{X: row.x * scale, Y: row.y * scale, Z: row.z * scale}
+		if strings.EqualFold(row.label, "central") {
+			origin = p
+			continue
+		}
+		positions = append(positions, p)
+	}
+	for i := range positions {
+		positions[i] = positions[i].Sub(origin)
+	}
+	return positions, nil
+}
+
+type positionRow struct {
+	label   string
+	x, y, z float64
+	unit    string
+}
+
+func parseCoordinates(fields []string) (x, y, z float64, err error) {
+	values := make([]float64, 3)
+	for i := range values {
+		if values[i], err = strconv.ParseFloat(strings.TrimSpace(fields[i]), 64); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	return values[0], values[1], values[2], nil
+}
+
+func readXYZ(r io.Reader, units string) ([]positionRow, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("empty XYZ file")
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil {
+		return nil, fmt.Errorf("the first line of an XYZ file should be the number of sites: %w", err)
+	}
+	if scanner.Scan() {
+		for _, field := range strings.Fields(scanner.Text()) {
+			if key, value, ok := strings.Cut(field, "="); ok && strings.EqualFold(key, "units") {
+				units = value
+			}
+		}
+	}
+
+	var rows []positionRow
+	for scanner.Scan() && len(rows) < count {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("line %q should read 'label x y z [unit]'", scanner.Text())
+		}
+		row := positionRow{label: fields[0], unit: units}
+		if row.x, row.y, row.z, err = parseCoordinates(fields[1:4]); err != nil {
+			return nil, err
+		}
+		if len(fields) > 4 {
+			row.unit = fields[4]
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) != count {
+		return nil, fmt.Errorf("expected %v sites, found %v", count, len(rows))
+	}
+	return rows, scanner.Err()
+}
+
+func readCSV(r io.Reader, units string) ([]positionRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty CSV file")
+	}
+
+	columns := map[string]int{"label": -1, "x": -1, "y": -1, "z": -1, "units": -1}
+	header := map[string]int{}
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "name", "species":
+			name = "label"
+		case "unit":
+			name = "units"
+		}
+		if _, ok := columns[name]; ok {
+			header[name] = i
+		}
+	}
+	// a header is recognised by naming any of the x, y and z columns, any other first row is data
+	_, x := header["x"]
+	_, y := header["y"]
+	_, z := header["z"]
+	if x || y || z {
+		for name, i := range header {
+			columns[name] = i
+		}
+		records = records[1:]
+	} else {
+		// no header: [label,] x, y, z[, units]
+		first := 0
+		if _, err := strconv.ParseFloat(strings.TrimSpace(records[0][0]), 64); err != nil {
+			columns["label"], first = 0, 1
+		}
+		columns["x"], columns["y"], columns["z"] = first, first+1, first+2
+		if len(records[0]) > first+3 {
+			columns["units"] = first + 3
+		}
+	}
+	if columns["x"] < 0 || columns["y"] < 0 || columns["z"] < 0 {
+		return nil, fmt.Errorf("the CSV header should name the x, y and z columns")
+	}
+
+	rows := make([]positionRow, 0, len(records))
+	for _, record := range records {
+		field := func(name string) string {
+			if i := columns[name]; i >= 0 && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		row := positionRow{label: field("label"), unit: units}
+		if row.x, row.y, row.z, err = parseCoordinates([]string{field("x"), field("y"), field("z")}); err != nil {
+			return nil, err
+		}
+		if unit := field("units"); unit != "" {
+			row.unit = unit
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,111 @@
+package cs_q_sim
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadPositions(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		units    string
+		target   string
+		want     []Position
+	}{
+		{
+			name:     "XYZ with units in the comment line",
+			filename: "tweezers.xyz",
+			content:  "3\nunits=um\ncentral 1.0 0.0 0.0\nKRb 2.0 0.0 0.0\nKRb 1.0 3.0 0.0\n",
+			target:   "um",
+			want:     []Position{{1, 0, 0}, {0, 3, 0}},
+		},
+		{
+			name:     "XYZ with a unit per line",
+			filename: "tweezers.xyz",
+			content:  "2\n\nKRb 1000.0 0.0 0.0 nm\nKRb 0.0 0.0 -2.0\n",
+			units:    "um",
+			target:   "m",
+			want:     []Position{{1e-6, 0, 0}, {0, 0, -2e-6}},
+		},
+		{
+			name:     "CSV with a header",
+			filename: "tweezers.csv",
+			content:  "label,z,y,x,units\ncentral,0,0,0,um\nbath,1.5,0,0,um\nbath,0,0,500,nm\n",
+			target:   "um",
+			want:     []Position{{0, 0, 1.5}, {0.5, 0, 0}},
+		},
+		{
+			name:     "CSV without a header",
+			filename: "tweezers.csv",
+			content:  "# x, y, z in micrometres\n1.0,2.0,3.0\n-1.0,0.0,0.5\n",
+			units:    "um",
+			target:   "um",
+			want:     []Position{{1, 2, 3}, {-1, 0, 0.5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.filename)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadPositions(path, tt.units, tt.target)
+			if err != nil {
+				t.Fatalf("ReadPositions() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ReadPositions() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				d := Position{X: got[i].X - tt.want[i].X, Y: got[i].Y - tt.want[i].Y, Z: got[i].Z - tt.want[i].Z}
+				if d.Norm() > 1e-12*math.Max(1, tt.want[i].Norm()) {
+					t.Errorf("ReadPositions() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadPositions_Errors(t *testing.T) {
+	tests := []struct {
+		filename string
+		content  string
+		wantErr  string
+	}{
+		{filename: "count.xyz", content: "3\n\nKRb 1 0 0\n", wantErr: "expected 3 sites"},
+		{filename: "unit.csv", content: "1,0,0,parsec\n", wantErr: "unknown length unit \"parsec\""},
+		{filename: "labelled-unit.csv", content: "KRb,1,0,0,parsec\n", wantErr: "unknown length unit \"parsec\""},
+		{filename: "columns.csv", content: "label,x,y\nKRb,1,2\n", wantErr: "x, y and z columns"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.filename)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadPositions(path, "um", "um"); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ReadPositions() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBathStates_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bath.xyz")
+	if err := os.WriteFile(path, []byte("2\nunits=um\nKRb 0 0 2\nKRb 0 1 0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	conf := PhysicsConfig{Geometry: "file", PositionsFile: path, BathCount: 2, Units: "atomic", TiltAngle: 0.5}
+	want := []State{{Angle: 0, Distance: 2}, {Angle: 1, Distance: 1}}
+	got := BathStates(conf)
+	for i := range want {
+		if math.Abs(got[i].Angle-want[i].Angle) > 1e-12 || math.Abs(got[i].Distance-want[i].Distance) > 1e-12 {
+			t.Errorf("BathStates() = %v, want %v", got, want)
+		}
+	}
+}
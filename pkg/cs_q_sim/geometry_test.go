@@ -0,0 +1,161 @@
+package cs_q_sim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGeometryPositions_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		conf PhysicsConfig
+	}{
+		{name: "unknown geometry", conf: PhysicsConfig{Geometry: "gauss", BathCount: 4, ConstantDistance: 1}},
+		{name: "too many sites", conf: PhysicsConfig{Geometry: "icosahedron", BathCount: 20, ConstantDistance: 1}},
+		{name: "too few sites", conf: PhysicsConfig{Geometry: "cube", BathCount: 4, ConstantDistance: 1}},
+		{name: "sites at the central atom", conf: PhysicsConfig{Geometry: "ring", BathCount: 4}},
+		{name: "occupation mask", conf: PhysicsConfig{Geometry: "square", BathCount: 3, Lattice: LatticeConfig{LatticeConstant: 1, CutoffRadius: 1, Occupation: []int{1, 1, 0, 0}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GeometryPositions(tt.conf); err == nil {
+				t.Errorf("GeometryPositions() should fail")
+			}
+		})
+	}
+}
+
+func TestRegisterGeometry(t *testing.T) {
+	RegisterGeometry("test-axis", VertexGeometry([]Position{{Z: 1}, {X: 1}}))
+	defer delete(geometries, "test-axis")
+
+	bath := BathStates(PhysicsConfig{Geometry: "test-axis", BathCount: 2, ConstantDistance: 2})
+	want := []State{{Angle: 1, Distance: 2, Position: Position{Z: 2}}, {Angle: 0, Distance: 2, Position: Position{X: 2}}}
+	for i := range want {
+		if math.Abs(bath[i].Angle-want[i].Angle) > 1e-12 || bath[i].Position != want[i].Position {
+			t.Errorf("BathStates() = %v, want %v", bath, want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterGeometry() should panic for a taken name")
+		}
+	}()
+	RegisterGeometry("ring", ringGeometry{})
+}
+
+func TestPolyhedra(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+	}{
+		{name: "tetrahedron", count: 4},
+		{name: "octahedron", count: 6},
+		{name: "cube", count: 8},
+		{name: "icosahedron", count: 12},
+		{name: "truncated-tetrahedron", count: 12},
+		{name: "cuboctahedron", count: 12},
+		{name: "dodecahedron", count: 20},
+		{name: "truncated-octahedron", count: 24},
+		{name: "truncated-cube", count: 24},
+		{name: "rhombicuboctahedron", count: 24},
+		{name: "snub-cube", count: 24},
+		{name: "icosidodecahedron", count: 30},
+		{name: "truncated-cuboctahedron", count: 48},
+		{name: "truncated-icosahedron", count: 60},
+		{name: "truncated-dodecahedron", count: 60},
+		{name: "rhombicosidodecahedron", count: 60},
+		{name: "snub-dodecahedron", count: 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			positions, err := GeometryPositions(PhysicsConfig{Geometry: tt.name, BathCount: tt.count, ConstantDistance: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// all vertices lie on the sphere, balance out and have the same nearest neighbour distance
+			var centroid Position
+			edge := 0.0
+			for i, p := range positions {
+				if math.Abs(p.Norm()-2) > 1e-6 {
+					t.Errorf("vertex %v = %v is not at distance 2", i, p)
+				}
+				centroid = centroid.Add(p)
+				nearest := math.Inf(1)
+				for j, q := range positions {
+					if i != j {
+						nearest = math.Min(nearest, p.Sub(q).Norm())
+					}
+				}
+				if i == 0 {
+					edge = nearest
+				} else if math.Abs(nearest-edge) > 1e-5 {
+					t.Errorf("vertex %v has nearest neighbour at %v, want %v", i, nearest, edge)
+				}
+			}
+			if centroid.Norm() > 1e-5 {
+				t.Errorf("centroid = %v, want the origin", centroid)
+			}
+		})
+	}
+}
+
+func TestPlatonicGeometry(t *testing.T) {
+	for count := 1; count <= 20; count++ {
+		_, err := GeometryPositions(PhysicsConfig{Geometry: "platonic", BathCount: count, ConstantDistance: 1})
+		_, solid := platonicSolids[count]
+		if (err == nil) != solid {
+			t.Errorf("GeometryPositions() for %v sites: error = %v", count, err)
+		}
+	}
+}
+
+func TestRingGeometries(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    PhysicsConfig
+		want    []Position
+		wantErr bool
+	}{
+		{
+			name: "cylinder",
+			conf: PhysicsConfig{Geometry: "cylinder", BathCount: 4, ConstantDistance: 1, Rings: RingsConfig{Layers: 2, Spacing: 2}},
+			want: []Position{{0, 1, -1}, {0, -1, -1}, {0, 1, 1}, {0, -1, 1}},
+		},
+		{
+			name: "staggered cylinder",
+			conf: PhysicsConfig{Geometry: "cylinder", BathCount: 4, ConstantDistance: 1, Rings: RingsConfig{Layers: 2, Spacing: 2, Staggered: true}},
+			want: []Position{{0, 1, -1}, {0, -1, -1}, {1, 0, 1}, {-1, 0, 1}},
+		},
+		{
+			name: "double ring",
+			conf: PhysicsConfig{Geometry: "double-ring", BathCount: 4, ConstantDistance: 1, Rings: RingsConfig{RadiusRatio: 2}},
+			want: []Position{{0, 1, 0}, {0, -1, 0}, {0, 2, 0}, {0, -2, 0}},
+		},
+		{
+			name:    "uneven layers",
+			conf:    PhysicsConfig{Geometry: "cylinder", BathCount: 5, ConstantDistance: 1, Rings: RingsConfig{Layers: 2, Spacing: 1}},
+			wantErr: true,
+		},
+		{
+			name:    "no radius ratio",
+			conf:    PhysicsConfig{Geometry: "double-ring", BathCount: 4, ConstantDistance: 1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GeometryPositions(tt.conf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GeometryPositions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for i := range tt.want {
+				if got[i].Sub(tt.want[i]).Norm() > 1e-12 {
+					t.Errorf("GeometryPositions() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
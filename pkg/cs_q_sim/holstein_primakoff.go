@@ -0,0 +1,99 @@
+package cs_q_sim
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+/*
+Holstein-Primakoff mapping of a bath polarised along +z.
+
+To the lowest order S_j^+ ≈ sqrt(2s) a_j, S_j^- ≈ sqrt(2s) a_j† and S_j^z = s - a_j†a_j.
+The flip-flop term Σ_j c_j (S_0^+ S_j^- + S_0^- S_j^+) then couples the central spin only to the collective mode
+b = Σ_j c_j a_j / G, with G = sqrt(Σ_j c_j^2), and the remaining modes stay empty when starting from a polarised bath:
+
+	H = b0 S_0^z + b (N s - b†b) + sqrt(2s) G (S_0^+ b† + S_0^- b)
+
+For the XXX model the Ising term 2 c_j S_0^z S_j^z contributes 2 s (Σ_j c_j) S_0^z - 2 S_0^z Σ_j c_j a_j†a_j,
+the last sum being projected onto the collective mode as (Σ_j c_j^3 / G^2) b†b.
+*/
+
+// CollectiveCoupling returns G = sqrt(Σ_j c_j^2), the strength with which the central spin couples to the collective bath mode
+func (s *System) CollectiveCoupling() float64 {
+	g := 0.0
+	for j := 1; j <= len(s.Bath); j++ {
+		g += math.Pow(s.InteractionAt(j), 2)
+	}
+	return math.Sqrt(g)
+}
+
+// HolsteinPrimakoffHamiltonian returns the Hamiltonian of the central spin coupled to the collective mode of the bath, truncated at maxBosons quanta.
+// The basis is the central spin ⊗ |0>, ..., |maxBosons>
+func (s *System) HolsteinPrimakoffHamiltonian(b0, b float64, maxBosons int) *mat.SymDense {
+	spin := s.PhysicsConfig.Spin
+	bc := float64(len(s.Bath))
+	g := s.CollectiveCoupling()
+
+	var h, term mat.Dense
+	h.Kronecker(Sz(spin), BosonId(maxBosons))
+	h.Scale(b0, &h)
+
+	term.Kronecker(Id(spin), Number(maxBosons))
+	term.Scale(-b, &term)
+	h.Add(&h, &term)
+
+	var identity mat.Dense
+	identity.Kronecker(Id(spin), BosonId(maxBosons))
+	identity.Scale(b*bc*spin, &identity)
+	h.Add(&h, &identity)
+
+	var flipFlop, conj mat.Dense
+	flipFlop.Kronecker(Sp(spin), Creation(maxBosons))
+	conj.Kronecker(Sm(spin), Annihilation(maxBosons))
+	flipFlop.Add(&flipFlop, &conj)
+	flipFlop.Scale(math.Sqrt(2.0*spin)*g, &flipFlop)
+	h.Add(&h, &flipFlop)
+
+	if s.PhysicsConfig.Model == "XXX" {
+		sum, cubes := 0.0, 0.0
+		for j := 1; j <= len(s.Bath); j++ {
+			c := s.InteractionAt(j)
+			sum += c
+			cubes += math.Pow(c, 3)
+		}
+		var ising, projected mat.Dense
+		ising.Kronecker(Sz(spin), BosonId(maxBosons))
+		ising.Scale(2.0*spin*sum, &ising)
+		h.Add(&h, &ising)
+		if g > 0 {
+			projected.Kronecker(Sz(spin), Number(maxBosons))
+			projected.Scale(-2.0*cubes/(g*g), &projected)
+			h.Add(&h, &projected)
+		}
+	}
+
+	return mat.NewSymDense(h.RawMatrix().Cols, h.RawMatrix().Data)
+}
+
+// HolsteinPrimakoffVector maps a ket such as "duuuu" onto the central spin ⊗ collective mode space, with the collective mode in its vacuum.
+// The bath part of the ket has to be fully polarised along +z
+func HolsteinPrimakoffVector(ket string, spin float64, maxBosons int) *mat.VecDense {
+	for _, state := range ket[1:] {
+		if state != 'u' {
+			panic("Holstein-Primakoff mapping requires a bath polarised along +z")
+		}
+	}
+	spinDim := int(2*spin + 1)
+	central := mat.NewDense(spinDim, 1, ManyBodyVector(ket[:1], spinDim))
+	var v mat.Dense
+	v.Kronecker(central, mat.NewDense(maxBosons+1, 1, FockVector(0, maxBosons)))
+	return mat.NewVecDense(spinDim*(maxBosons+1), v.RawMatrix().Data)
+}
+
+// HolsteinPrimakoffObservable lifts a central spin operator to the central spin ⊗ collective mode space
+func HolsteinPrimakoffObservable(operator *mat.Dense, maxBosons int) Observable {
+	var o mat.Dense
+	o.Kronecker(operator, BosonId(maxBosons))
+	return Observable{Dense: o}
+}
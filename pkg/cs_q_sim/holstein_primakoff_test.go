@@ -0,0 +1,102 @@
+package cs_q_sim
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestSystem_CollectiveCoupling(t *testing.T) {
+	tests := []struct {
+		name         string
+		coefficients []float64
+		want         float64
+	}{
+		{
+			name:         "3-4-5",
+			coefficients: []float64{0.0, 3.0, 4.0},
+			want:         5.0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &System{
+				Bath:          make([]State, len(tt.coefficients)-1),
+				PhysicsConfig: PhysicsConfig{InteractionCoefficients: tt.coefficients},
+			}
+			if got := s.CollectiveCoupling(); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("System.CollectiveCoupling() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSystem_HolsteinPrimakoffAgainstExact(t *testing.T) {
+	type args struct {
+		ket       string
+		maxBosons int
+		times     []float64
+	}
+	tests := []struct {
+		name          string
+		physicsConfig PhysicsConfig
+		args          args
+	}{
+		{
+			name: "single flip, XX",
+			physicsConfig: PhysicsConfig{
+				Spin:                    0.5,
+				InteractionCoefficients: []float64{0.0, 1.0, 0.5, -0.3},
+			},
+			args: args{ket: "duuu", maxBosons: 3, times: []float64{0.0, 0.7, 1.9, 4.2}},
+		},
+		{
+			// the Ising term keeps a single flip in the collective mode only for equal couplings
+			name: "single flip, XXX",
+			physicsConfig: PhysicsConfig{
+				Spin:                    0.5,
+				Model:                   "XXX",
+				InteractionCoefficients: []float64{0.0, 0.8, 0.8, 0.8},
+			},
+			args: args{ket: "duuu", maxBosons: 3, times: []float64{0.0, 0.7, 1.9, 4.2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b0, b := 1.3, 1.0
+			s := &System{
+				Bath:          make([]State, len(tt.args.ket)-1),
+				PhysicsConfig: tt.physicsConfig,
+			}
+			spinDim := int(2*tt.physicsConfig.Spin + 1)
+
+			exactKet := mat.NewVecDense(int(math.Pow(float64(spinDim), float64(len(tt.args.ket)))), ManyBodyVector(tt.args.ket, spinDim))
+			exactEigen := s.Diagonalize(s.Hamiltonian(b0, b))
+			exactGram := Grammian(exactKet, exactEigen.EigenVectors)
+			exactObservable := Observable{Dense: *ManyBodyOperator(Sz(tt.physicsConfig.Spin), 0, len(tt.args.ket))}
+
+			hpKet := HolsteinPrimakoffVector(tt.args.ket, tt.physicsConfig.Spin, tt.args.maxBosons)
+			hpEigen := s.Diagonalize(s.HolsteinPrimakoffHamiltonian(b0, b, tt.args.maxBosons))
+			hpGram := Grammian(hpKet, hpEigen.EigenVectors)
+			hpObservable := HolsteinPrimakoffObservable(Sz(tt.physicsConfig.Spin), tt.args.maxBosons)
+
+			for _, time := range tt.args.times {
+				exact := exactObservable.ExpectationValue(Evolve(exactKet, time, exactEigen.EigenValues, exactEigen.EigenVectors, exactGram))
+				hp := hpObservable.ExpectationValue(Evolve(hpKet, time, hpEigen.EigenValues, hpEigen.EigenVectors, hpGram))
+				if math.Abs(exact-hp) > 1e-8 {
+					t.Errorf("t = %v: Holstein-Primakoff <Sz> = %v, exact <Sz> = %v", time, hp, exact)
+				}
+			}
+		})
+	}
+}
+
+func TestHolsteinPrimakoffVectorPanic(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+	_ = HolsteinPrimakoffVector("dudu", 0.5, 2)
+}
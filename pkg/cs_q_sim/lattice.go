@@ -0,0 +1,113 @@
+package cs_q_sim
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// latticeTolerance absorbs rounding when comparing distances of lattice sites
+const latticeTolerance = 1e-9
+
+// LatticeSites returns the sites of a square, triangular or (simple) cubic lattice with the central atom at the origin,
+// within the cut-off radius and ordered by distance, then by azimuth and height. The two-dimensional lattices lie in the xy plane
+func LatticeSites(lattice string, constant, cutoff float64) ([]Position, error) {
+	if constant <= 0 || cutoff <= 0 {
+		return nil, fmt.Errorf("a lattice needs a positive lattice constant and cut-off radius")
+	}
+	n := int(math.Ceil(cutoff/constant)) + 1
+	var a, b, c Position
+	switch lattice {
+	case "square":
+		a, b = Position{X: 1}, Position{Y: 1}
+	case "triangular":
+		a, b = Position{X: 1}, Position{X: 0.5, Y: math.Sqrt(3) / 2}
+		n = int(math.Ceil(2*cutoff/(math.Sqrt(3)*constant))) + 1
+	case "cubic":
+		a, b, c = Position{X: 1}, Position{Y: 1}, Position{Z: 1}
+	default:
+		return nil, fmt.Errorf("unknown lattice %q", lattice)
+	}
+	layers := 0
+	if c != (Position{}) {
+		layers = n
+	}
+
+	var sites []Position
+	for i := -n; i <= n; i++ {
+		for j := -n; j <= n; j++ {
+			for k := -layers; k <= layers; k++ {
+				p := a.Scale(float64(i)).Add(b.Scale(float64(j))).Add(c.Scale(float64(k))).Scale(constant)
+				if r := p.Norm(); r > latticeTolerance && r <= cutoff+latticeTolerance {
+					sites = append(sites, p)
+				}
+			}
+		}
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		if ri, rj := sites[i].Norm(), sites[j].Norm(); math.Abs(ri-rj) > latticeTolerance {
+			return ri < rj
+		}
+		if ai, aj := math.Atan2(sites[i].Y, sites[i].X), math.Atan2(sites[j].Y, sites[j].X); math.Abs(ai-aj) > latticeTolerance {
+			return ai < aj
+		}
+		return sites[i].Z < sites[j].Z
+	})
+	return sites, nil
+}
+
+/*
+LatticePositions occupies 'count' sites of the lattice configured in lc.
+An explicit Occupation mask (1 for an occupied site, in the order of LatticeSites) has to hold exactly 'count' ones.
+Otherwise 'count' sites are drawn at random with lc.Seed. Without a cut-off radius, the smallest one with at least count / FillingFraction sites is used
+*/
+func LatticePositions(lattice string, count int, lc LatticeConfig) ([]Position, error) {
+	cutoff := lc.CutoffRadius
+	if cutoff == 0 {
+		if lc.FillingFraction <= 0 || lc.FillingFraction > 1 {
+			return nil, fmt.Errorf("a lattice needs a cut-off radius or a filling fraction in (0, 1]")
+		}
+		needed := int(math.Ceil(float64(count) / lc.FillingFraction))
+		for cutoff = lc.LatticeConstant; ; cutoff += lc.LatticeConstant {
+			sites, err := LatticeSites(lattice, lc.LatticeConstant, cutoff)
+			if err != nil {
+				return nil, err
+			}
+			if len(sites) >= needed {
+				break
+			}
+		}
+	}
+	sites, err := LatticeSites(lattice, lc.LatticeConstant, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lc.Occupation) > 0 {
+		if len(lc.Occupation) != len(sites) {
+			return nil, fmt.Errorf("the occupation mask has %v entries, but the lattice has %v sites within the cut-off", len(lc.Occupation), len(sites))
+		}
+		var positions []Position
+		for i, occupied := range lc.Occupation {
+			if occupied != 0 {
+				positions = append(positions, sites[i])
+			}
+		}
+		if len(positions) != count {
+			return nil, fmt.Errorf("the occupation mask fills %v sites, but the bath count is %v", len(positions), count)
+		}
+		return positions, nil
+	}
+
+	if count > len(sites) {
+		return nil, fmt.Errorf("cannot place %v molecules on the %v sites within the cut-off", count, len(sites))
+	}
+	chosen := rand.New(rand.NewSource(lc.Seed)).Perm(len(sites))[:count]
+	sort.Ints(chosen)
+	positions := make([]Position, count)
+	for i, site := range chosen {
+		positions[i] = sites[site]
+	}
+	return positions, nil
+}
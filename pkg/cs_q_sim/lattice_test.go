@@ -0,0 +1,77 @@
+package cs_q_sim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLatticeSites(t *testing.T) {
+	tests := []struct {
+		lattice     string
+		cutoff      float64
+		wantCount   int
+		wantNearest float64
+	}{
+		{lattice: "square", cutoff: 2.0, wantCount: 4, wantNearest: 2.0},
+		{lattice: "square", cutoff: 2.0 * math.Sqrt2, wantCount: 8, wantNearest: 2.0},
+		{lattice: "triangular", cutoff: 2.0, wantCount: 6, wantNearest: 2.0},
+		{lattice: "triangular", cutoff: 2.0 * math.Sqrt(3), wantCount: 12, wantNearest: 2.0},
+		{lattice: "cubic", cutoff: 2.0 * math.Sqrt2, wantCount: 18, wantNearest: 2.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lattice, func(t *testing.T) {
+			sites, err := LatticeSites(tt.lattice, 2.0, tt.cutoff)
+			if err != nil {
+				t.Fatalf("LatticeSites() error = %v", err)
+			}
+			if len(sites) != tt.wantCount {
+				t.Errorf("LatticeSites() holds %v sites, want %v", len(sites), tt.wantCount)
+			}
+			if math.Abs(sites[0].Norm()-tt.wantNearest) > 1e-12 {
+				t.Errorf("the nearest site is at %v, want %v", sites[0].Norm(), tt.wantNearest)
+			}
+			for i, p := range sites {
+				if i > 0 && p.Norm() < sites[i-1].Norm()-latticeTolerance {
+					t.Errorf("LatticeSites() is not ordered by distance: %v", sites)
+				}
+				if tt.lattice != "cubic" && p.Z != 0 {
+					t.Errorf("site %v is off the plane", p)
+				}
+			}
+		})
+	}
+}
+
+func TestLatticePositions(t *testing.T) {
+	mask := LatticeConfig{LatticeConstant: 1.0, CutoffRadius: 1.0, Occupation: []int{1, 0, 1, 0}}
+	got, err := LatticePositions("square", 2, mask)
+	if err != nil {
+		t.Fatalf("LatticePositions() error = %v", err)
+	}
+	sites, _ := LatticeSites("square", 1.0, 1.0)
+	if got[0] != sites[0] || got[1] != sites[2] {
+		t.Errorf("LatticePositions() = %v, want sites 0 and 2 of %v", got, sites)
+	}
+	if _, err := LatticePositions("square", 3, mask); err == nil {
+		t.Errorf("LatticePositions() should fail when the mask does not match the bath count")
+	}
+
+	random := LatticeConfig{LatticeConstant: 1.0, FillingFraction: 0.5, Seed: 7}
+	got, err = LatticePositions("triangular", 6, random)
+	if err != nil {
+		t.Fatalf("LatticePositions() error = %v", err)
+	}
+	seen := map[Position]bool{}
+	for _, p := range got {
+		if seen[p] {
+			t.Errorf("LatticePositions() occupies %v twice", p)
+		}
+		seen[p] = true
+		if p.Norm() > 2.0+latticeTolerance {
+			t.Errorf("site %v lies beyond the cut-off of the two nearest shells", p)
+		}
+	}
+	if again, _ := LatticePositions("triangular", 6, random); again[0] != got[0] || again[5] != got[5] {
+		t.Errorf("LatticePositions() is not reproducible for a fixed seed")
+	}
+}
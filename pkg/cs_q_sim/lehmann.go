@@ -0,0 +1,101 @@
+package cs_q_sim
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// LehmannPeaks is the Lehmann representation C(t) = Σ_k w_k exp(i ω_k t) of a correlation function,
+// whose Fourier transform is the sum of delta peaks Σ_k w_k δ(ω - ω_k)
+type LehmannPeaks struct {
+	Frequencies []float64
+	Weights     []complex128
+}
+
+/*
+LehmannCorrelation returns the peaks of C(t) = <ψ| A(t) B(0) |ψ>.
+Inserting the eigenbasis, C(t) = Σ_{m,n} <ψ|m> <m|A|n> <n|B|ψ> exp(i (E_m - E_n) t), so the peaks sit at E_m - E_n.
+Peaks closer than 1e-9 are merged, and peaks with vanishing weight are dropped
+*/
+func LehmannCorrelation(a, b *mat.Dense, state *mat.VecDense, eigen Eigen) LehmannPeaks {
+	vectors := eigen.EigenVectors
+	dim := len(eigen.EigenValues)
+
+	var aEigen, temp mat.Dense
+	temp.Mul(a, vectors)
+	aEigen.Mul(vectors.T(), &temp)
+
+	bState := mat.NewVecDense(dim, nil)
+	bState.MulVec(b, state)
+	bEigen := mat.NewVecDense(dim, nil)
+	bEigen.MulVec(vectors.T(), bState)
+	overlaps := mat.NewVecDense(dim, nil)
+	overlaps.MulVec(vectors.T(), state)
+
+	type peak struct {
+		frequency float64
+		weight    float64
+	}
+	var peaks []peak
+	for m := 0; m < dim; m++ {
+		if overlaps.AtVec(m) == 0 {
+			continue
+		}
+		for n := 0; n < dim; n++ {
+			w := overlaps.AtVec(m) * aEigen.At(m, n) * bEigen.AtVec(n)
+			if math.Abs(w) < 1e-14 {
+				continue
+			}
+			peaks = append(peaks, peak{eigen.EigenValues[m] - eigen.EigenValues[n], w})
+		}
+	}
+	sort.Slice(peaks, func(i, j int) bool {
+		return peaks[i].frequency < peaks[j].frequency
+	})
+
+	var out LehmannPeaks
+	for _, p := range peaks {
+		last := len(out.Frequencies) - 1
+		if last >= 0 && p.frequency-out.Frequencies[last] < 1e-9 {
+			out.Weights[last] += complex(p.weight, 0)
+			continue
+		}
+		out.Frequencies = append(out.Frequencies, p.frequency)
+		out.Weights = append(out.Weights, complex(p.weight, 0))
+	}
+	return out
+}
+
+// At returns C(t)
+func (p LehmannPeaks) At(time float64) complex128 {
+	var c complex128
+	for k, w := range p.Weights {
+		c += w * cmplx.Exp(complex(0, p.Frequencies[k]*time))
+	}
+	return c
+}
+
+// Spectral returns the real part of the spectral function Σ_k w_k δ(ω - ω_k), with every delta peak broadened to a "lorentzian" or a "gaussian" of the given width
+func (p LehmannPeaks) Spectral(omega, width float64, broadening string) float64 {
+	var profile func(x float64) float64
+	switch broadening {
+	case "lorentzian":
+		profile = func(x float64) float64 {
+			return width / math.Pi / (x*x + width*width)
+		}
+	case "gaussian":
+		profile = func(x float64) float64 {
+			return math.Exp(-x*x/(2*width*width)) / (width * math.Sqrt(2*math.Pi))
+		}
+	default:
+		panic("unknown broadening: " + broadening)
+	}
+	a := 0.0
+	for k, w := range p.Weights {
+		a += real(w) * profile(omega-p.Frequencies[k])
+	}
+	return a
+}
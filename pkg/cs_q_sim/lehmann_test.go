@@ -0,0 +1,67 @@
+package cs_q_sim
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLehmannCorrelation(t *testing.T) {
+	tests := []struct {
+		name string
+		ket  string
+	}{
+		{name: "polarised bath", ket: "uuu"},
+		{name: "superposition", ket: "pdu"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sites := len(tt.ket)
+			s := &System{
+				Bath:          make([]State, sites-1),
+				PhysicsConfig: PhysicsConfig{Spin: 0.5, Model: "XXX", InteractionCoefficients: []float64{0.0, 1.0, -0.6}},
+			}
+			eigen := s.Diagonalize(s.Hamiltonian(1.1, 0.7))
+			dim := len(eigen.EigenValues)
+			ket := mat.NewVecDense(dim, ManyBodyVector(tt.ket, 2))
+			peaks := LehmannCorrelation(ManyBodyOperator(Sp(0.5), 0, sites), ManyBodyOperator(Sm(0.5), 0, sites), ket, eigen)
+
+			bKet := mat.NewVecDense(dim, nil)
+			bKet.MulVec(ManyBodyOperator(Sm(0.5), 0, sites), ket)
+			for _, time := range []float64{0.0, 0.4, 3.3} {
+				psi := Evolve(ket, time, eigen.EigenValues, eigen.EigenVectors, Grammian(ket, eigen.EigenVectors))
+				phi := Evolve(bKet, time, eigen.EigenValues, eigen.EigenVectors, Grammian(bKet, eigen.EigenVectors))
+				want := InnerProduct(psi, ApplySiteOperator(Sp(0.5), 0, sites, phi))
+				if got := peaks.At(time); cmplx.Abs(got-want) > 1e-10 {
+					t.Errorf("LehmannPeaks.At(%v) = %v, want %v", time, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLehmannPeaks_Spectral(t *testing.T) {
+	peaks := LehmannPeaks{Frequencies: []float64{-1.0, 2.0}, Weights: []complex128{0.25, 0.5}}
+	tests := []struct {
+		name       string
+		broadening string
+		width      float64
+	}{
+		{name: "gaussian sum rule", broadening: "gaussian", width: 0.2},
+		{name: "lorentzian sum rule", broadening: "lorentzian", width: 0.01},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			integral := 0.0
+			dOmega := 1e-3
+			for omega := -50.0; omega < 50.0; omega += dOmega {
+				integral += peaks.Spectral(omega, tt.width, tt.broadening) * dOmega
+			}
+			if want := real(peaks.At(0)); math.Abs(integral-want) > 1e-3 {
+				t.Errorf("∫ A(ω) dω = %v, want C(0) = %v", integral, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package cs_q_sim
+
+import (
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/plot/plotter"
+)
+
+/*
+AdjacentGapRatio returns the mean <r> of r_n = min(s_n, s_{n+1}) / max(s_n, s_{n+1}) over consecutive level spacings s_n = E_{n+1} - E_n.
+It needs no unfolding; <r> ≈ 0.386 for Poissonian (integrable) and ≈ 0.531 for GOE (chaotic) spectra.
+Pairs of gaps both smaller than 'tolerance' are skipped, and NaN is returned for fewer than three levels
+*/
+func AdjacentGapRatio(energies []float64, tolerance float64) float64 {
+	sorted := append([]float64(nil), energies...)
+	sort.Float64s(sorted)
+	sum, count := 0.0, 0
+	for n := 0; n+2 < len(sorted); n++ {
+		s1 := sorted[n+1] - sorted[n]
+		s2 := sorted[n+2] - sorted[n+1]
+		if math.Max(s1, s2) < tolerance {
+			continue
+		}
+		sum += math.Min(s1, s2) / math.Max(s1, s2)
+		count++
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return sum / float64(count)
+}
+
+// UnfoldSpectrum maps sorted energies E_i onto N(E_i), a polynomial of the given degree fitted to the staircase function, so the unfolded levels have unit mean spacing
+func UnfoldSpectrum(energies []float64, degree int) []float64 {
+	sorted := append([]float64(nil), energies...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if degree > n-1 {
+		degree = n - 1
+	}
+
+	// rescale to [-1, 1] to keep the Vandermonde matrix well conditioned
+	lo, hi := sorted[0], sorted[n-1]
+	scale := func(e float64) float64 {
+		if hi == lo {
+			return 0
+		}
+		return 2*(e-lo)/(hi-lo) - 1
+	}
+	vandermonde := mat.NewDense(n, degree+1, nil)
+	staircase := mat.NewVecDense(n, nil)
+	for i, e := range sorted {
+		x := scale(e)
+		for k := 0; k <= degree; k++ {
+			vandermonde.Set(i, k, math.Pow(x, float64(k)))
+		}
+		staircase.SetVec(i, float64(i))
+	}
+	var coefficients mat.VecDense
+	if err := coefficients.SolveVec(vandermonde, staircase); err != nil {
+		panic(err)
+	}
+
+	unfolded := make([]float64, n)
+	for i, e := range sorted {
+		x := scale(e)
+		for k := 0; k <= degree; k++ {
+			unfolded[i] += coefficients.AtVec(k) * math.Pow(x, float64(k))
+		}
+	}
+	return unfolded
+}
+
+// LevelSpacings returns the spacings between consecutive levels
+func LevelSpacings(levels []float64) []float64 {
+	spacings := make([]float64, 0, len(levels))
+	for i := 1; i < len(levels); i++ {
+		spacings = append(spacings, levels[i]-levels[i-1])
+	}
+	return spacings
+}
+
+// SpacingHistogram returns the normalised distribution P(s) of the spacings in 'bins' equal bins over [0, maxSpacing], as (bin centre, density) points
+func SpacingHistogram(spacings []float64, bins int, maxSpacing float64) plotter.XYs {
+	width := maxSpacing / float64(bins)
+	counts := make([]float64, bins)
+	for _, s := range spacings {
+		if s < 0 || s >= maxSpacing {
+			continue
+		}
+		counts[int(s/width)]++
+	}
+	xys := make(plotter.XYs, bins)
+	for i, c := range counts {
+		density := 0.0
+		if len(spacings) > 0 {
+			density = c / (float64(len(spacings)) * width)
+		}
+		xys[i] = plotter.XY{X: (float64(i) + 0.5) * width, Y: density}
+	}
+	return xys
+}
+
+// InverseParticipationRatio returns Σ_i |v_i|^4 of a normalised vector: 1 for a basis state and 1/dim for a state spread evenly over the basis
+func InverseParticipationRatio(v mat.Vector) float64 {
+	ipr := 0.0
+	for i := 0; i < v.Len(); i++ {
+		ipr += math.Pow(v.AtVec(i), 4)
+	}
+	return ipr
+}
@@ -0,0 +1,96 @@
+package cs_q_sim
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestAdjacentGapRatio(t *testing.T) {
+	type args struct {
+		energies  []float64
+		tolerance float64
+	}
+	tests := []struct {
+		name string
+		args args
+		want float64
+	}{
+		{
+			name: "picket fence",
+			args: args{energies: []float64{3, 0, 1, 2}, tolerance: 1e-12},
+			want: 1.0,
+		},
+		{
+			name: "alternating gaps",
+			args: args{energies: []float64{0, 1, 3, 4}, tolerance: 1e-12},
+			want: 0.5,
+		},
+		{
+			name: "degenerate gaps are skipped",
+			args: args{energies: []float64{0, 0, 0, 1, 3}, tolerance: 1e-12},
+			want: (0.0 + 0.5) / 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AdjacentGapRatio(tt.args.energies, tt.args.tolerance); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("AdjacentGapRatio() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdjacentGapRatio_Poisson(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	energies := make([]float64, 20000)
+	for i := range energies {
+		energies[i] = r.Float64()
+	}
+	if got, want := AdjacentGapRatio(energies, 0), 2*math.Ln2-1; math.Abs(got-want) > 0.01 {
+		t.Errorf("AdjacentGapRatio() of uncorrelated levels = %v, want %v", got, want)
+	}
+}
+
+func TestUnfoldSpectrum(t *testing.T) {
+	// levels with a quadratic density are unfolded onto unit spacings
+	energies := make([]float64, 50)
+	for i := range energies {
+		energies[i] = math.Sqrt(float64(i))
+	}
+	for _, s := range LevelSpacings(UnfoldSpectrum(energies, 2)) {
+		if math.Abs(s-1) > 1e-8 {
+			t.Errorf("unfolded spacing = %v, want 1", s)
+		}
+	}
+}
+
+func TestSpacingHistogram(t *testing.T) {
+	xys := SpacingHistogram([]float64{0.1, 0.2, 0.6, 5.0}, 2, 1.0)
+	want := []float64{1.0, 0.5}
+	for i := range want {
+		if math.Abs(xys[i].Y-want[i]) > 1e-12 {
+			t.Errorf("SpacingHistogram() = %v, want densities %v", xys, want)
+		}
+	}
+}
+
+func TestInverseParticipationRatio(t *testing.T) {
+	tests := []struct {
+		name string
+		v    mat.Vector
+		want float64
+	}{
+		{name: "basis state", v: mat.NewVecDense(4, []float64{0, 1, 0, 0}), want: 1},
+		{name: "uniform state", v: mat.NewVecDense(4, []float64{0.5, -0.5, 0.5, 0.5}), want: 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InverseParticipationRatio(tt.v); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("InverseParticipationRatio() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
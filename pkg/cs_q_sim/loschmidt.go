@@ -0,0 +1,24 @@
+package cs_q_sim
+
+import (
+	"math"
+	"math/cmplx"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ReturnProbability returns the fidelity |<Ψ(0)|Ψ(t)>|^2 = |Σ_j |<E_j|Ψ(0)>|^2 exp(-i E_j t)|^2, given the overlaps from Grammian
+func ReturnProbability(time float64, energies []float64, grammian *mat.Dense) float64 {
+	var amplitude complex128
+	for j, e := range energies {
+		amplitude += complex(math.Pow(grammian.At(0, j), 2), 0) * cmplx.Exp(complex(0, -e*time))
+	}
+	return math.Pow(cmplx.Abs(amplitude), 2)
+}
+
+// LoschmidtEcho returns |<Ψ(0)| exp(i H_2 t) exp(-i H_1 t) |Ψ(0)>|^2 = |<Ψ_2(t)|Ψ_1(t)>|^2, given the state Ψ_1(t) already evolved under H_1.
+// Only the evolution under H_2, given by its eigen-decomposition, is computed here
+func LoschmidtEcho(state []complex128, initialVector *mat.VecDense, time float64, second Eigen, secondGrammian *mat.Dense) float64 {
+	psi2 := Evolve(initialVector, time, second.EigenValues, second.EigenVectors, secondGrammian)
+	return math.Pow(cmplx.Abs(InnerProduct(psi2, state)), 2)
+}
@@ -0,0 +1,46 @@
+package cs_q_sim
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestReturnProbability(t *testing.T) {
+	s := &System{
+		Bath:          []State{{}, {}},
+		PhysicsConfig: PhysicsConfig{Spin: 0.5, InteractionCoefficients: []float64{0.0, 1.0, 0.4}},
+	}
+	eigen := s.Diagonalize(s.Hamiltonian(1.0, 0.8))
+	initialKet := mat.NewVecDense(8, ManyBodyVector("duu", 2))
+	gram := Grammian(initialKet, eigen.EigenVectors)
+
+	for _, time := range []float64{0.0, 0.3, 2.5} {
+		state := Evolve(initialKet, time, eigen.EigenValues, eigen.EigenVectors, gram)
+		want := math.Pow(cmplx.Abs(InnerProduct(realState(initialKet.RawVector().Data), state)), 2)
+		if got := ReturnProbability(time, eigen.EigenValues, gram); math.Abs(got-want) > 1e-10 {
+			t.Errorf("ReturnProbability(%v) = %v, want %v", time, got, want)
+		}
+		if got := LoschmidtEcho(state, initialKet, time, eigen, gram); math.Abs(got-1) > 1e-10 {
+			t.Errorf("LoschmidtEcho(%v) without a perturbation = %v, want 1", time, got)
+		}
+	}
+}
+
+func TestLoschmidtEcho(t *testing.T) {
+	// a perturbation of the central field only adds a phase to an Sz eigenstate
+	s := &System{
+		Bath:          []State{{}},
+		PhysicsConfig: PhysicsConfig{Spin: 0.5, InteractionCoefficients: []float64{0.0, 1.0}},
+	}
+	first := s.Diagonalize(s.Hamiltonian(1.0, 1.0))
+	second := s.Diagonalize(s.Hamiltonian(1.3, 1.0))
+	initialKet := mat.NewVecDense(4, ManyBodyVector("uu", 2))
+	state := Evolve(initialKet, 1.7, first.EigenValues, first.EigenVectors, Grammian(initialKet, first.EigenVectors))
+	got := LoschmidtEcho(state, initialKet, 1.7, second, Grammian(initialKet, second.EigenVectors))
+	if math.Abs(got-1) > 1e-10 {
+		t.Errorf("LoschmidtEcho() = %v, want 1", got)
+	}
+}
@@ -63,3 +63,26 @@ func ManyBodyVector(states string, dim int) []float64 {
 	}
 	return u.RawMatrix().Data
 }
+
+// ManyBodyOperatorWithDims returns the one-body operator acting on slot 'particle' of a Hilbert space whose slots have local dimensions 'dims'.
+// Unlike ManyBodyOperator the slots need not share a dimension, which allows spins next to truncated bosonic modes
+func ManyBodyOperatorWithDims(operator *mat.Dense, particle int, dims []int) *mat.Dense {
+	if r, _ := operator.Dims(); particle >= len(dims) || r != dims[particle] {
+		panic("operator does not match the local dimension of its slot")
+	}
+	var n *mat.Dense
+	for slot, dim := range dims {
+		factor := operator
+		if slot != particle {
+			factor = BosonId(dim - 1)
+		}
+		if n == nil {
+			n = mat.DenseCopyOf(factor)
+			continue
+		}
+		var temp mat.Dense
+		temp.Kronecker(n, factor)
+		n = &temp
+	}
+	return n
+}
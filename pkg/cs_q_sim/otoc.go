@@ -0,0 +1,64 @@
+package cs_q_sim
+
+import (
+	"math/cmplx"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// mulVecReal returns m |v> for a real matrix m, or m^T |v> if trans is set
+func mulVecReal(m mat.Matrix, v []complex128, trans bool) []complex128 {
+	if trans {
+		m = m.T()
+	}
+	r, _ := m.Dims()
+	re := make([]float64, len(v))
+	im := make([]float64, len(v))
+	for i, c := range v {
+		re[i] = real(c)
+		im[i] = imag(c)
+	}
+	reOut := mat.NewVecDense(r, nil)
+	imOut := mat.NewVecDense(r, nil)
+	reOut.MulVec(m, mat.NewVecDense(len(v), re))
+	imOut.MulVec(m, mat.NewVecDense(len(v), im))
+	out := make([]complex128, r)
+	for i := range out {
+		out[i] = complex(reOut.AtVec(i), imOut.AtVec(i))
+	}
+	return out
+}
+
+// HeisenbergApply returns W(t)|state> = exp(iHt) W exp(-iHt) |state>, with the time evolution taken in the eigenbasis of H
+func HeisenbergApply(w mat.Matrix, eigen Eigen, time float64, state []complex128) []complex128 {
+	x := mulVecReal(eigen.EigenVectors, state, true)
+	for n, e := range eigen.EigenValues {
+		x[n] *= cmplx.Exp(complex(0, -e*time))
+	}
+	x = mulVecReal(w, mulVecReal(eigen.EigenVectors, x, false), false)
+	x = mulVecReal(eigen.EigenVectors, x, true)
+	for n, e := range eigen.EigenValues {
+		x[n] *= cmplx.Exp(complex(0, e*time))
+	}
+	return mulVecReal(eigen.EigenVectors, x, false)
+}
+
+/*
+OTOC returns the out-of-time-order correlator F(t) = <ψ| W†(t) V† W(t) V |ψ> of the many-body operators w and v.
+Writing it as <V W(t) ψ | W(t) V ψ> needs two Heisenberg-picture applications of W per time
+*/
+func OTOC(w, v mat.Matrix, eigen Eigen, time float64, state []complex128) complex128 {
+	left := mulVecReal(v, HeisenbergApply(w, eigen, time, state), false)
+	right := HeisenbergApply(w, eigen, time, mulVecReal(v, state, false))
+	return InnerProduct(left, right)
+}
+
+// OTOCs returns OTOC(w, v, ...) for every v, computing W(t)|ψ> only once
+func OTOCs(w mat.Matrix, vs []mat.Matrix, eigen Eigen, time float64, state []complex128) []complex128 {
+	wState := HeisenbergApply(w, eigen, time, state)
+	out := make([]complex128, len(vs))
+	for i, v := range vs {
+		out[i] = InnerProduct(mulVecReal(v, wState, false), HeisenbergApply(w, eigen, time, mulVecReal(v, state, false)))
+	}
+	return out
+}
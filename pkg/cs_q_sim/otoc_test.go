@@ -0,0 +1,72 @@
+package cs_q_sim
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestHeisenbergApply(t *testing.T) {
+	s := &System{
+		Bath:          []State{{}, {}},
+		PhysicsConfig: PhysicsConfig{Spin: 0.5, InteractionCoefficients: []float64{0.0, 1.0, 0.4}},
+	}
+	eigen := s.Diagonalize(s.Hamiltonian(1.0, 0.8))
+	ket := mat.NewVecDense(8, ManyBodyVector("dup", 2))
+	gram := Grammian(ket, eigen.EigenVectors)
+	w := ManyBodyOperator(Sz(0.5), 0, 3)
+	observable := Observable{Dense: *w}
+
+	for _, time := range []float64{0.0, 0.9, 2.4} {
+		want := observable.ExpectationValue(Evolve(ket, time, eigen.EigenValues, eigen.EigenVectors, gram))
+		if got := InnerProduct(realState(ket.RawVector().Data), HeisenbergApply(w, eigen, time, realState(ket.RawVector().Data))); cmplx.Abs(got-complex(want, 0)) > 1e-10 {
+			t.Errorf("<ψ|W(%v)|ψ> = %v, want %v", time, got, want)
+		}
+	}
+}
+
+func TestOTOC(t *testing.T) {
+	s := &System{
+		Bath:          []State{{}, {}},
+		PhysicsConfig: PhysicsConfig{Spin: 0.5, Model: "XXX", InteractionCoefficients: []float64{0.0, 1.0, 0.4}},
+	}
+	eigen := s.Diagonalize(s.Hamiltonian(1.0, 0.8))
+	state := realState(ManyBodyVector("dup", 2))
+
+	pauli := func(slot int) *mat.Dense {
+		p := ManyBodyOperator(Sz(0.5), slot, 3)
+		p.Scale(2, p)
+		return p
+	}
+
+	if got := OTOC(pauli(0), pauli(1), eigen, 0, state); cmplx.Abs(got-1) > 1e-10 {
+		t.Errorf("OTOC() at t = 0 = %v, want 1", got)
+	}
+	ones := []float64{1, 1, 1, 1, 1, 1, 1, 1}
+	if got := OTOC(pauli(0), mat.NewDiagDense(8, ones), eigen, 1.3, state); cmplx.Abs(got-1) > 1e-10 {
+		t.Errorf("OTOC() with V = 1 = %v, want 1", got)
+	}
+	if got := OTOC(pauli(0), pauli(1), eigen, 1.3, state); cmplx.Abs(got) > 1+1e-10 || math.Abs(real(got)-1) < 1e-6 {
+		t.Errorf("OTOC() at t = 1.3 = %v, want a scrambled value inside the unit disc", got)
+	}
+}
+
+func TestOTOCs(t *testing.T) {
+	s := &System{
+		Bath:          []State{{}, {}},
+		PhysicsConfig: PhysicsConfig{Spin: 0.5, InteractionCoefficients: []float64{0.0, 1.0, 0.4}},
+	}
+	eigen := s.Diagonalize(s.Hamiltonian(1.0, 0.8))
+	state := realState(ManyBodyVector("duu", 2))
+	w := ManyBodyOperator(Sz(0.5), 0, 3)
+	vs := []mat.Matrix{ManyBodyOperator(Sz(0.5), 1, 3), ManyBodyOperator(Sp(0.5), 2, 3)}
+
+	got := OTOCs(w, vs, eigen, 0.8, state)
+	for i, v := range vs {
+		if want := OTOC(w, v, eigen, 0.8, state); cmplx.Abs(got[i]-want) > 1e-12 {
+			t.Errorf("OTOCs()[%v] = %v, want %v", i, got[i], want)
+		}
+	}
+}
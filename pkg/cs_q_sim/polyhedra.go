@@ -0,0 +1,213 @@
+package cs_q_sim
+
+import (
+	"fmt"
+	"math"
+)
+
+// golden is the golden ratio φ
+var golden = 0.5 + math.Sqrt(5.0)*0.5
+
+// SignRule selects which sign changes of the seed coordinates PermutedVertices takes
+type SignRule int
+
+const (
+	AllSigns    SignRule = iota
+	EvenSigns            // an even number of minus signs, as in the truncated tetrahedron
+	ChiralSigns          // the number of minus signs has the parity of the permutation, as in the snub solids
+)
+
+/*
+PermutedVertices returns the unit vectors along the sign changes allowed by 'signs' and the coordinate permutations of the seed points, without duplicates.
+With cyclic set only the cyclic (even) permutations are taken, as in the coordinates of the icosahedral solids.
+This covers the usual coordinates of the Platonic and Archimedean solids
+*/
+func PermutedVertices(seeds []Position, cyclic bool, signs SignRule) []Position {
+	// the first three permutations are even, the others odd
+	permutations := [][3]int{{0, 1, 2}, {1, 2, 0}, {2, 0, 1}}
+	if !cyclic {
+		permutations = append(permutations, [3]int{0, 2, 1}, [3]int{2, 1, 0}, [3]int{1, 0, 2})
+	}
+	seen := map[[3]int64]bool{}
+	var vertices []Position
+	for _, seed := range seeds {
+		c := [3]float64{seed.X, seed.Y, seed.Z}
+		for k, p := range permutations {
+			for flips := 0; flips < 8; flips++ {
+				minuses := flips&1 + flips>>1&1 + flips>>2&1
+				if (signs == EvenSigns && minuses%2 != 0) || (signs == ChiralSigns && minuses%2 != k/3) {
+					continue
+				}
+				var v [3]float64
+				for i := range v {
+					v[i] = c[p[i]]
+					if flips&(1<<i) != 0 {
+						v[i] = -v[i]
+					}
+				}
+				vertex := Position{v[0], v[1], v[2]}
+				vertex = vertex.Scale(1 / vertex.Norm())
+				key := [3]int64{int64(math.Round(vertex.X * 1e9)), int64(math.Round(vertex.Y * 1e9)), int64(math.Round(vertex.Z * 1e9))}
+				if !seen[key] {
+					seen[key] = true
+					vertices = append(vertices, vertex)
+				}
+			}
+		}
+	}
+	return vertices
+}
+
+func tetrahedronVertices() []Position {
+	a := 1 / math.Sqrt(3.0)
+	return []Position{{a, a, a}, {a, -a, -a}, {-a, a, -a}, {-a, -a, a}}
+}
+
+func octahedronVertices() []Position {
+	return PermutedVertices([]Position{{1, 0, 0}}, false, AllSigns)
+}
+
+func cuboctahedronVertices() []Position {
+	return PermutedVertices([]Position{{1, 1, 0}}, false, AllSigns)
+}
+
+func truncatedOctahedronVertices() []Position {
+	return PermutedVertices([]Position{{0, 1, 2}}, false, AllSigns)
+}
+
+func truncatedCubeVertices() []Position {
+	return PermutedVertices([]Position{{math.Sqrt2 - 1, 1, 1}}, false, AllSigns)
+}
+
+func rhombicuboctahedronVertices() []Position {
+	return PermutedVertices([]Position{{1, 1, 1 + math.Sqrt2}}, false, AllSigns)
+}
+
+func truncatedCuboctahedronVertices() []Position {
+	return PermutedVertices([]Position{{1, 1 + math.Sqrt2, 1 + 2*math.Sqrt2}}, false, AllSigns)
+}
+
+func truncatedTetrahedronVertices() []Position {
+	return PermutedVertices([]Position{{3, 1, 1}}, false, EvenSigns)
+}
+
+func snubCubeVertices() []Position {
+	// t is the tribonacci constant, the real root of t^3 = t^2 + t + 1
+	t := (1 + math.Cbrt(19+3*math.Sqrt(33)) + math.Cbrt(19-3*math.Sqrt(33))) / 3
+	return PermutedVertices([]Position{{1, 1 / t, t}}, false, ChiralSigns)
+}
+
+func snubDodecahedronVertices() []Position {
+	// xi is the real root of xi^3 - 2 xi = φ
+	xi := math.Cbrt(golden/2+math.Sqrt(golden-5.0/27)/2) + math.Cbrt(golden/2-math.Sqrt(golden-5.0/27)/2)
+	alpha := xi - 1/xi
+	beta := xi*golden + golden*golden + golden/xi
+	return PermutedVertices([]Position{
+		{2 * alpha, 2, 2 * beta},
+		{alpha + beta/golden + golden, -alpha*golden + beta + 1/golden, alpha/golden + beta*golden - 1},
+		{-alpha/golden + beta*golden + 1, -alpha + beta/golden - golden, alpha*golden + beta - 1/golden},
+		{-alpha/golden + beta*golden - 1, alpha - beta/golden - golden, alpha*golden + beta + 1/golden},
+		{alpha + beta/golden - golden, alpha*golden - beta + 1/golden, alpha/golden + beta*golden + 1},
+	}, true, ChiralSigns)
+}
+
+func icosidodecahedronVertices() []Position {
+	return PermutedVertices([]Position{{0, 0, golden}, {0.5, golden / 2, golden * golden / 2}}, true, AllSigns)
+}
+
+func truncatedIcosahedronVertices() []Position {
+	return PermutedVertices([]Position{{0, 1, 3 * golden}, {1, 2 + golden, 2 * golden}, {golden, 2, 2*golden + 1}}, true, AllSigns)
+}
+
+func truncatedDodecahedronVertices() []Position {
+	return PermutedVertices([]Position{{0, 1 / golden, 2 + golden}, {1 / golden, golden, 2 * golden}, {golden, 2, golden + 1}}, true, AllSigns)
+}
+
+func rhombicosidodecahedronVertices() []Position {
+	return PermutedVertices([]Position{{1, 1, math.Pow(golden, 3)}, {golden * golden, golden, 2 * golden}, {2 + golden, 0, golden * golden}}, true, AllSigns)
+}
+
+// platonicGeometry picks the Platonic solid with BathCount vertices
+type platonicGeometry struct{}
+
+var platonicSolids = map[int]func() []Position{
+	4:  tetrahedronVertices,
+	6:  octahedronVertices,
+	8:  cubeVertices,
+	12: icosahedronVertices,
+	20: dodecahedronVertices,
+}
+
+func (platonicGeometry) SiteCount(conf PhysicsConfig) (int, error) {
+	if _, ok := platonicSolids[conf.BathCount]; !ok {
+		return 0, fmt.Errorf("no Platonic solid has %v vertices, expected 4, 6, 8, 12 or 20", conf.BathCount)
+	}
+	return conf.BathCount, nil
+}
+
+func (platonicGeometry) Positions(conf PhysicsConfig) ([]Position, error) {
+	return VertexGeometry(platonicSolids[conf.BathCount]()).Positions(conf)
+}
+
+// cylinderGeometry stacks Rings.Layers rings of radius ConstantDistance along z, Rings.Spacing apart and centred on the central atom
+type cylinderGeometry struct{}
+
+func (cylinderGeometry) SiteCount(PhysicsConfig) (int, error) {
+	return 0, nil
+}
+
+func (cylinderGeometry) Positions(conf PhysicsConfig) ([]Position, error) {
+	rc := conf.Rings
+	perRing, err := sitesPerRing(conf.BathCount, rc.Layers)
+	if err != nil {
+		return nil, err
+	}
+	var positions []Position
+	for layer := 0; layer < rc.Layers; layer++ {
+		z := (float64(layer) - float64(rc.Layers-1)/2) * rc.Spacing
+		for _, p := range ringPositions(perRing, conf.ConstantDistance, rc.Staggered && layer%2 == 1) {
+			positions = append(positions, p.Add(Position{Z: z}))
+		}
+	}
+	return positions, nil
+}
+
+// doubleRingGeometry places two concentric rings in the xy plane with radii ConstantDistance and Rings.RadiusRatio times that
+type doubleRingGeometry struct{}
+
+func (doubleRingGeometry) SiteCount(PhysicsConfig) (int, error) {
+	return 0, nil
+}
+
+func (doubleRingGeometry) Positions(conf PhysicsConfig) ([]Position, error) {
+	rc := conf.Rings
+	perRing, err := sitesPerRing(conf.BathCount, 2)
+	if err != nil {
+		return nil, err
+	}
+	if rc.RadiusRatio <= 0 {
+		return nil, fmt.Errorf("a double ring needs a positive radius ratio")
+	}
+	positions := ringPositions(perRing, conf.ConstantDistance, false)
+	return append(positions, ringPositions(perRing, rc.RadiusRatio*conf.ConstantDistance, rc.Staggered)...), nil
+}
+
+func sitesPerRing(count, rings int) (int, error) {
+	if rings < 1 || count%rings != 0 {
+		return 0, fmt.Errorf("%v bath sites cannot be split evenly into %v rings", count, rings)
+	}
+	return count / rings, nil
+}
+
+// ringPositions spaces n sites on a circle in the xy plane as the ring geometry does, turned by half a spacing if staggered
+func ringPositions(n int, radius float64, staggered bool) []Position {
+	positions := make([]Position, n)
+	for j := range positions {
+		angle := float64(2*j) * math.Pi / float64(n)
+		if staggered {
+			angle += math.Pi / float64(n)
+		}
+		positions[j] = Position{math.Sin(angle), math.Cos(angle), 0.0}.Scale(radius)
+	}
+	return positions
+}
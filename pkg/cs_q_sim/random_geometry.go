@@ -0,0 +1,79 @@
+package cs_q_sim
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// maxPlacementAttempts bounds the rejection sampling of a single site
+const maxPlacementAttempts = 100000
+
+// DisorderRadius returns the radius of the ball (or disc) holding 'count' sites at the density set by the DisorderConfig
+func DisorderRadius(count int, dc DisorderConfig) (float64, error) {
+	dimension := dc.Dimension
+	if dimension == 0 {
+		dimension = 3
+	}
+	if dimension != 2 && dimension != 3 {
+		return 0, fmt.Errorf("random geometries are either 2 or 3 dimensional, not %v", dimension)
+	}
+	density := dc.Density
+	if density <= 0 {
+		if dc.FillingFraction <= 0 || dc.ExclusionRadius <= 0 {
+			return 0, fmt.Errorf("a random geometry needs a density, or a filling fraction together with an exclusion radius")
+		}
+		if dimension == 2 {
+			density = dc.FillingFraction / (math.Pi * math.Pow(dc.ExclusionRadius/2, 2))
+		} else {
+			density = dc.FillingFraction / (4.0 / 3.0 * math.Pi * math.Pow(dc.ExclusionRadius/2, 3))
+		}
+	}
+	if dimension == 2 {
+		return math.Sqrt(float64(count) / (math.Pi * density)), nil
+	}
+	return math.Cbrt(3 * float64(count) / (4 * math.Pi * density)), nil
+}
+
+// RandomPositions draws 'count' sites uniformly inside the ball (or the disc in the xy plane) of DisorderRadius, rejecting the ones
+// closer than the exclusion radius to the central atom or to the sites already placed
+func RandomPositions(count int, dc DisorderConfig, rng *rand.Rand) ([]Position, error) {
+	radius, err := DisorderRadius(count, dc)
+	if err != nil {
+		return nil, err
+	}
+	planar := dc.Dimension == 2
+
+	positions := make([]Position, 0, count)
+	fits := func(p Position) bool {
+		if p.Norm() < dc.ExclusionRadius {
+			return false
+		}
+		for _, q := range positions {
+			if p.Sub(q).Norm() < dc.ExclusionRadius {
+				return false
+			}
+		}
+		return true
+	}
+	for len(positions) < count {
+		attempt := 0
+		for ; attempt < maxPlacementAttempts; attempt++ {
+			p := Position{X: 2*rng.Float64() - 1, Y: 2*rng.Float64() - 1}
+			if !planar {
+				p.Z = 2*rng.Float64() - 1
+			}
+			if p.Norm() > 1 {
+				continue
+			}
+			if p = p.Scale(radius); fits(p) {
+				positions = append(positions, p)
+				break
+			}
+		}
+		if attempt == maxPlacementAttempts {
+			return nil, fmt.Errorf("could not place site %v of %v within radius %v; lower the density or the exclusion radius", len(positions), count, radius)
+		}
+	}
+	return positions, nil
+}
@@ -0,0 +1,68 @@
+package cs_q_sim
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestDisorderRadius(t *testing.T) {
+	tests := []struct {
+		name string
+		dc   DisorderConfig
+		want float64
+	}{
+		{name: "density", dc: DisorderConfig{Density: 3 / (4 * math.Pi)}, want: math.Cbrt(8)},
+		{name: "planar density", dc: DisorderConfig{Density: 2 / math.Pi, Dimension: 2}, want: 2},
+		{name: "filling fraction", dc: DisorderConfig{FillingFraction: 0.5, ExclusionRadius: 2}, want: math.Cbrt(16)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DisorderRadius(8, tt.dc)
+			if err != nil {
+				t.Fatalf("DisorderRadius() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("DisorderRadius() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if _, err := DisorderRadius(8, DisorderConfig{FillingFraction: 0.5}); err == nil {
+		t.Errorf("DisorderRadius() without an exclusion radius should fail")
+	}
+}
+
+func TestRandomPositions(t *testing.T) {
+	for _, dc := range []DisorderConfig{
+		{Density: 0.1, ExclusionRadius: 1.0, Seed: 3},
+		{Density: 0.1, ExclusionRadius: 1.0, Dimension: 2, Seed: 4},
+	} {
+		radius, _ := DisorderRadius(12, dc)
+		positions, err := RandomPositions(12, dc, rand.New(rand.NewSource(dc.Seed)))
+		if err != nil {
+			t.Fatalf("RandomPositions() error = %v", err)
+		}
+		for i, p := range positions {
+			if p.Norm() > radius || p.Norm() < dc.ExclusionRadius {
+				t.Errorf("site %v at %v lies outside the shell [%v, %v]", i, p, dc.ExclusionRadius, radius)
+			}
+			if dc.Dimension == 2 && p.Z != 0 {
+				t.Errorf("site %v at %v is off the plane", i, p)
+			}
+			for _, q := range positions[:i] {
+				if p.Sub(q).Norm() < dc.ExclusionRadius {
+					t.Errorf("sites %v and %v are closer than the exclusion radius", p, q)
+				}
+			}
+		}
+		again, _ := RandomPositions(12, dc, rand.New(rand.NewSource(dc.Seed)))
+		if !reflect.DeepEqual(positions, again) {
+			t.Errorf("RandomPositions() is not reproducible for a fixed seed")
+		}
+	}
+
+	if _, err := RandomPositions(50, DisorderConfig{Density: 1.0, ExclusionRadius: 2.0}, rand.New(rand.NewSource(1))); err == nil {
+		t.Errorf("RandomPositions() should fail when the sites cannot be packed")
+	}
+}
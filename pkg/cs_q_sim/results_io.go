@@ -26,7 +26,10 @@ type ResultsIO struct {
 	Values   struct {
 		System System `mapstructure:"system"`
 	} `mapstructure:"values"`
-	XYs []plotter.XYs `mapstructure:"xyss"`
+	XYs     []plotter.XYs      `mapstructure:"xyss"`
+	Labels  []string           `mapstructure:"labels"`  // Labels[i] names the series XYs[i]
+	Scalars map[string]float64 `mapstructure:"scalars"` // Single-number results, such as cross-check errors
+	Units   map[string]string  `mapstructure:"units"`   // Units of the results, by quantity, see OutputUnits
 }
 
 type DiagonalizationResultsIO struct {
@@ -36,6 +39,9 @@ type DiagonalizationResultsIO struct {
 }
 
 func (r *ResultsIO) Write(conf FilesConfig) {
+	if r.Units == nil {
+		r.Units = OutputUnits(r.Values.System.PhysicsConfig)
+	}
 	r.Filename += ".yaml"
 	path := conf.OutputsDir
 	file, err := os.Create(path + r.Filename)
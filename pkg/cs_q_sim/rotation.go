@@ -0,0 +1,104 @@
+package cs_q_sim
+
+import (
+	"fmt"
+	"math"
+)
+
+// Rotation is a 3×3 rotation matrix acting on positions
+type Rotation [3][3]float64
+
+// IdentityRotation leaves every position in place
+func IdentityRotation() Rotation {
+	return Rotation{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+}
+
+// EulerRotation returns Rz(alpha)·Ry(beta)·Rz(gamma), the z-y-z convention, with angles in radians
+func EulerRotation(alpha, beta, gamma float64) Rotation {
+	z := Position{Z: 1.0}
+	y := Position{Y: 1.0}
+	return AxisAngleRotation(z, alpha).Mul(AxisAngleRotation(y, beta)).Mul(AxisAngleRotation(z, gamma))
+}
+
+// AxisAngleRotation returns the right-handed rotation by 'angle' radians about 'axis', which need not be normalised
+func AxisAngleRotation(axis Position, angle float64) Rotation {
+	n := axis.Scale(1 / axis.Norm())
+	c, s := math.Cos(angle), math.Sin(angle)
+	u := [3]float64{n.X, n.Y, n.Z}
+	k := [3][3]float64{{0, -n.Z, n.Y}, {n.Z, 0, -n.X}, {-n.Y, n.X, 0}}
+	var r Rotation
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			r[i][j] = (1-c)*u[i]*u[j] + s*k[i][j]
+			if i == j {
+				r[i][j] += c
+			}
+		}
+	}
+	return r
+}
+
+// Apply rotates the position p
+func (r Rotation) Apply(p Position) Position {
+	v := [3]float64{p.X, p.Y, p.Z}
+	var out [3]float64
+	for i := range out {
+		for j := range v {
+			out[i] += r[i][j] * v[j]
+		}
+	}
+	return Position{out[0], out[1], out[2]}
+}
+
+// Mul returns the rotation r·o, which applies o first
+func (r Rotation) Mul(o Rotation) Rotation {
+	var m Rotation
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				m[i][j] += r[i][k] * o[k][j]
+			}
+		}
+	}
+	return m
+}
+
+// Transpose returns the inverse rotation
+func (r Rotation) Transpose() Rotation {
+	var t Rotation
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			t[i][j] = r[j][i]
+		}
+	}
+	return t
+}
+
+// Rotation returns the rotation of the geometry described by the config: the Euler angles, or else the axis-angle pair, or the identity
+func (oc OrientationConfig) Rotation() (Rotation, error) {
+	switch {
+	case len(oc.Euler) > 0 && len(oc.Axis) > 0:
+		return Rotation{}, fmt.Errorf("the orientation should be given either by Euler angles or by an axis and an angle, not both")
+	case len(oc.Euler) > 0:
+		if len(oc.Euler) != 3 {
+			return Rotation{}, fmt.Errorf("expected 3 Euler angles (alpha, beta, gamma), got %v", len(oc.Euler))
+		}
+		return EulerRotation(oc.Euler[0]*math.Pi, oc.Euler[1]*math.Pi, oc.Euler[2]*math.Pi), nil
+	case len(oc.Axis) > 0:
+		if len(oc.Axis) != 3 {
+			return Rotation{}, fmt.Errorf("the rotation axis should have 3 components, got %v", len(oc.Axis))
+		}
+		axis := Position{oc.Axis[0], oc.Axis[1], oc.Axis[2]}
+		if axis.Norm() == 0 {
+			return Rotation{}, fmt.Errorf("the rotation axis should not vanish")
+		}
+		return AxisAngleRotation(axis, oc.Angle*math.Pi), nil
+	}
+	return IdentityRotation(), nil
+}
+
+// FieldDirection returns the unit vector at polar angle 'polar' and azimuth 'azimuth', both in units of π
+func FieldDirection(polar, azimuth float64) Position {
+	theta, phi := polar*math.Pi, azimuth*math.Pi
+	return Position{math.Sin(theta) * math.Cos(phi), math.Sin(theta) * math.Sin(phi), math.Cos(theta)}
+}
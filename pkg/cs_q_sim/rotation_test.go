@@ -0,0 +1,85 @@
+package cs_q_sim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEulerRotation(t *testing.T) {
+	tests := []struct {
+		name               string
+		alpha, beta, gamma float64
+		in, want           Position
+	}{
+		{name: "identity", in: Position{1, 2, 3}, want: Position{1, 2, 3}},
+		{name: "about z", alpha: math.Pi / 2, in: Position{X: 1}, want: Position{Y: 1}},
+		{name: "about y", beta: math.Pi / 2, in: Position{Z: 1}, want: Position{X: 1}},
+		{name: "z-y-z", alpha: math.Pi / 2, beta: math.Pi / 2, gamma: math.Pi / 2, in: Position{X: 1}, want: Position{X: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := EulerRotation(tt.alpha, tt.beta, tt.gamma)
+			if got := r.Apply(tt.in); got.Sub(tt.want).Norm() > 1e-12 {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+			if back := r.Transpose().Apply(r.Apply(tt.in)); back.Sub(tt.in).Norm() > 1e-12 {
+				t.Errorf("Transpose() does not undo the rotation: %v", back)
+			}
+		})
+	}
+}
+
+func TestAxisAngleRotation(t *testing.T) {
+	// a third of a turn about (1, 1, 1) cycles the axes
+	r := AxisAngleRotation(Position{1, 1, 1}, 2*math.Pi/3)
+	if got := r.Apply(Position{X: 1}); got.Sub(Position{Y: 1}).Norm() > 1e-12 {
+		t.Errorf("Apply() = %v, want (0, 1, 0)", got)
+	}
+	euler := EulerRotation(0.3, 0.7, -1.1)
+	got := AxisAngleRotation(Position{Z: 1}, 0.3).Mul(AxisAngleRotation(Position{Y: 1}, 0.7)).Mul(AxisAngleRotation(Position{Z: 1}, -1.1))
+	for i := range got {
+		for j := range got[i] {
+			if math.Abs(got[i][j]-euler[i][j]) > 1e-12 {
+				t.Fatalf("axis-angle product = %v, want %v", got, euler)
+			}
+		}
+	}
+}
+
+func TestQuantisationAxis(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    PhysicsConfig
+		want    Position
+		wantErr bool
+	}{
+		{name: "default", want: Position{Z: 1}},
+		{name: "tilt", conf: PhysicsConfig{TiltAngle: 0.25}, want: Position{Y: math.Sqrt2 / 2, Z: math.Sqrt2 / 2}},
+		{
+			name: "turning the geometry about x matches the tilt",
+			conf: PhysicsConfig{Orientation: OrientationConfig{Axis: []float64{1, 0, 0}, Angle: 0.25}},
+			want: Position{Y: math.Sqrt2 / 2, Z: math.Sqrt2 / 2},
+		},
+		{name: "field direction", conf: PhysicsConfig{TiltAngle: 0.3, Orientation: OrientationConfig{Field: []float64{0.5, 0.5}}}, want: Position{Y: 1}},
+		{
+			name: "field in the frame of the geometry",
+			conf: PhysicsConfig{Orientation: OrientationConfig{Euler: []float64{0.5, 0, 0}, Field: []float64{0.5, 0}}},
+			want: Position{Y: -1},
+		},
+		{name: "euler and axis", conf: PhysicsConfig{Orientation: OrientationConfig{Euler: []float64{0, 0, 0}, Axis: []float64{0, 0, 1}}}, wantErr: true},
+		{name: "two euler angles", conf: PhysicsConfig{Orientation: OrientationConfig{Euler: []float64{0, 0}}}, wantErr: true},
+		{name: "vanishing axis", conf: PhysicsConfig{Orientation: OrientationConfig{Axis: []float64{0, 0, 0}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tt.wantErr {
+					t.Errorf("QuantisationAxis() panic = %v, wantErr %v", r, tt.wantErr)
+				}
+			}()
+			if got := QuantisationAxis(tt.conf); got.Sub(tt.want).Norm() > 1e-12 {
+				t.Errorf("QuantisationAxis() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package cs_q_sim
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Species describes a bath molecule or a central atom: DipoleMoment is the transition dipole (in D) that enters the coupling,
+// GFactor its magnetic g-factor and TransitionFrequency (in Hz) the splitting of the two levels that form the spin
+type Species struct {
+	Name                string
+	DipoleMoment        float64
+	GFactor             float64
+	TransitionFrequency float64
+}
+
+// bohrMagnetonFrequency is μB / h in Hz/T
+const bohrMagnetonFrequency = 13.996244936e9
+
+/*
+molecules holds the ground-state polar molecules, with the spin formed by the rotational levels N = 0 and N = 1: the transition dipole is
+d/√3 for the permanent dipole d and the transition frequency is 2B for the rotational constant B. The g-factors are the rotational ones
+of the closed-shell bialkalis and the electron spin one of CaF, approximate values to be overridden for precision work
+*/
+var molecules = map[string]Species{
+	"krb":  {Name: "KRb", DipoleMoment: 0.574 / math.Sqrt(3), GFactor: 0.014, TransitionFrequency: 2 * 1.1139e9},
+	"nak":  {Name: "NaK", DipoleMoment: 2.72 / math.Sqrt(3), GFactor: 0.0253, TransitionFrequency: 2 * 2.8217e9},
+	"rbcs": {Name: "RbCs", DipoleMoment: 1.225 / math.Sqrt(3), GFactor: 0.0062, TransitionFrequency: 2 * 0.490174e9},
+	"nacs": {Name: "NaCs", DipoleMoment: 4.75 / math.Sqrt(3), GFactor: 0.0174, TransitionFrequency: 2 * 1.7387e9},
+	"caf":  {Name: "CaF", DipoleMoment: 3.07 / math.Sqrt(3), GFactor: 2.0023, TransitionFrequency: 2 * 10.26754e9},
+}
+
+// rydbergElement holds the atomic mass (in u) and the quantum defects δ_l, l = 0, 1, ..., of an alkali atom; higher l have no defect
+type rydbergElement struct {
+	name    string
+	mass    float64
+	defects []float64
+}
+
+var rydbergElements = map[string]rydbergElement{
+	"rb": {name: "Rb", mass: 86.909180527, defects: []float64{3.1311804, 2.6480, 1.3480, 0.0165}},
+	"cs": {name: "Cs", mass: 132.905451961, defects: []float64{4.049325, 3.5750, 2.4708, 0.0334}},
+}
+
+// SpeciesNames lists the species known to LookupSpecies
+func SpeciesNames() []string {
+	var names []string
+	for _, s := range molecules {
+		names = append(names, s.Name)
+	}
+	for _, e := range rydbergElements {
+		names = append(names, e.name+" (Rydberg, with n and l)")
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+LookupSpecies returns the preset named by sc.Name (case-insensitive). For the Rydberg atoms Rb and Cs the spin is formed by the states
+(n, l) and (n, l+1) and their properties follow from the quantum defects: the transition frequency from the Rydberg formula, the transition dipole
+from the semiclassical radial matrix element 3/2 n*² √(1 - (l+1)²/n*²) a0 times the angular factor of the Δm = 0 transition from m = 0, and the g-factor
+from the Landé formula for J (l + 1/2 if unset)
+*/
+func LookupSpecies(sc SpeciesConfig) (Species, error) {
+	name := strings.ToLower(strings.TrimSpace(sc.Name))
+	if s, ok := molecules[name]; ok {
+		return s, nil
+	}
+	if e, ok := rydbergElements[strings.TrimPrefix(name, "rydberg-")]; ok {
+		return e.rydberg(sc.N, sc.L, sc.J)
+	}
+	return Species{}, fmt.Errorf("unknown species %q, expected one of %v", sc.Name, strings.Join(SpeciesNames(), ", "))
+}
+
+func (e rydbergElement) effectiveN(n, l int) float64 {
+	if l < len(e.defects) {
+		return float64(n) - e.defects[l]
+	}
+	return float64(n)
+}
+
+func (e rydbergElement) rydberg(n, l int, j float64) (Species, error) {
+	if l < 0 || n <= l+1 {
+		return Species{}, fmt.Errorf("a %v Rydberg state needs n > l + 1 and l >= 0, got n = %v, l = %v", e.name, n, l)
+	}
+	if j == 0 {
+		j = float64(l) + 0.5
+	}
+	if math.Abs(j-float64(l)) != 0.5 {
+		return Species{}, fmt.Errorf("j = %v is not l ± 1/2 for l = %v", j, l)
+	}
+	const rydbergInfinity = 3.2898419602508e15 // Hz
+	const electronMass = 5.48579909065e-4      // u
+	rydbergConstant := rydbergInfinity / (1 + electronMass/e.mass)
+
+	lower, upper := e.effectiveN(n, l), e.effectiveN(n, l+1)
+	frequency := rydbergConstant * (1/(lower*lower) - 1/(upper*upper))
+
+	nc := 0.5 * (lower + upper)
+	lmax := float64(l + 1)
+	radial := 1.5 * nc * nc * math.Sqrt(1-lmax*lmax/(nc*nc)) // a0
+	angular := math.Sqrt(lmax * lmax / ((2*lmax - 1) * (2*lmax + 1)))
+	dipole := radial * angular * ea0 / debye
+
+	const gs = 2.00231930436
+	jj, ll, ss := j*(j+1), float64(l*(l+1)), 0.75
+	g := (jj+ll-ss)/(2*jj) + gs*(jj-ll+ss)/(2*jj)
+
+	orbital := fmt.Sprintf("(l=%v)", l)
+	if l < len("SPDFGHIK") {
+		orbital = string("SPDFGHIK"[l])
+	}
+	return Species{
+		Name:                fmt.Sprintf("%v %v%v", e.name, n, orbital),
+		DipoleMoment:        dipole,
+		GFactor:             g,
+		TransitionFrequency: math.Abs(frequency),
+	}, nil
+}
+
+/*
+applySpecies fills in the dipole moments, magnetic fields and gyromagnetic ratios of the bath and the central atom from BathSpecies and AtomSpecies.
+Values set in the config take precedence. The presets are written in the units of the config (see UnitSystemConfig), before they are converted:
+fields given in T or G become the field that splits the levels by the transition frequency
+*/
+func applySpecies(conf *PhysicsConfig) error {
+	for _, target := range []struct {
+		sc     SpeciesConfig
+		dipole *float64
+		field  *float64
+		gamma  *float64
+	}{
+		{conf.BathSpecies, &conf.BathDipoleMoment, &conf.BathMagneticField, &conf.UnitSystem.BathGyromagneticRatio},
+		{conf.AtomSpecies, &conf.AtomDipoleMoment, &conf.CentralMagneticField, &conf.UnitSystem.CentralGyromagneticRatio},
+	} {
+		if target.sc.Name == "" {
+			continue
+		}
+		s, err := LookupSpecies(target.sc)
+		if err != nil {
+			return err
+		}
+		gamma := s.GFactor * bohrMagnetonFrequency
+		if *target.gamma == 0 && conf.UnitSystem.declared() {
+			*target.gamma = gamma
+		} else if *target.gamma != 0 {
+			gamma = *target.gamma
+		}
+		if *target.dipole == 0 {
+			if *target.dipole, err = dipoleInConfigUnits(*conf, s.DipoleMoment); err != nil {
+				return err
+			}
+		}
+		if *target.field == 0 {
+			if *target.field, err = fieldInConfigUnits(*conf, s.TransitionFrequency, gamma); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dipoleInConfigUnits expresses a dipole moment in D in the dipole unit of the config: the declared one, D for atomic units and C m otherwise
+func dipoleInConfigUnits(conf PhysicsConfig, value float64) (float64, error) {
+	symbol := "C m"
+	if conf.UnitSystem.declared() {
+		symbol = conf.UnitSystem.Dipole
+	} else if conf.Units == "atomic" {
+		symbol = "D"
+	}
+	to, err := ParseUnit(symbol, DipoleDimension)
+	if err != nil {
+		return 0, err
+	}
+	from, _ := ParseUnit("D", DipoleDimension)
+	return Convert(value, from, to)
+}
+
+// fieldInConfigUnits expresses a level splitting in Hz as a field in the units of the config: the declared field unit (the frequency unit
+// if unset), Hz for atomic units and J otherwise
+func fieldInConfigUnits(conf PhysicsConfig, frequency, gamma float64) (float64, error) {
+	us := conf.UnitSystem
+	if !us.declared() {
+		if conf.Units == "atomic" {
+			return frequency, nil
+		}
+		return frequency * planck, nil
+	}
+	if us.Field == "" {
+		us.Field = us.Frequency
+	}
+	field, err := us.fieldUnit()
+	if err != nil {
+		return 0, err
+	}
+	if field.Dimension == MagneticFieldDimension {
+		if gamma == 0 {
+			return 0, fmt.Errorf("cannot express the transition frequency as a field for a vanishing g-factor")
+		}
+		return frequency / gamma / field.SI, nil
+	}
+	hz, _ := ParseUnit("Hz", FrequencyDimension)
+	return Convert(frequency, hz, field)
+}
@@ -0,0 +1,99 @@
+package cs_q_sim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLookupSpecies(t *testing.T) {
+	tests := []struct {
+		name          string
+		sc            SpeciesConfig
+		wantDipole    float64
+		wantFrequency float64
+		wantG         float64
+		tolerance     float64
+		wantErr       bool
+	}{
+		// the dipole moment and field of config/examples/constants.yaml
+		{name: "CaF", sc: SpeciesConfig{Name: "caf"}, wantDipole: 1.77, wantFrequency: 20.535e9, wantG: 2.0023, tolerance: 2e-3},
+		{name: "KRb", sc: SpeciesConfig{Name: "KRb"}, wantDipole: 0.574 / math.Sqrt(3), wantFrequency: 2.2278e9, wantG: 0.014, tolerance: 1e-6},
+		// Rb 50S_1/2 - 50P: about 30.4 GHz, a radial element of 3/2 n*² a0 at the mean effective n* = 47.11 with the angular factor 1/√3,
+		// and g_J of an S_1/2 state equal to g_s
+		{name: "Rb 50S", sc: SpeciesConfig{Name: "Rb", N: 50}, wantDipole: 1.5 * 47.11 * 47.11 / math.Sqrt(3) * ea0 / debye, wantFrequency: 30.4e9, wantG: 2.0023, tolerance: 5e-3},
+		// P_3/2 has g_J = (2 + g_s) / 3 ≈ 4/3
+		{name: "Cs 60P3/2", sc: SpeciesConfig{Name: "rydberg-cs", N: 60, L: 1, J: 1.5}, wantG: 1.3341, tolerance: 1e-3},
+		{name: "unknown", sc: SpeciesConfig{Name: "H2O"}, wantErr: true},
+		{name: "Rydberg without n", sc: SpeciesConfig{Name: "Rb"}, wantErr: true},
+		{name: "invalid j", sc: SpeciesConfig{Name: "Cs", N: 40, L: 2, J: 0.5}, wantErr: true},
+	}
+	relative := func(got, want float64) float64 { return math.Abs(got-want) / math.Abs(want) }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LookupSpecies(tt.sc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LookupSpecies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantDipole != 0 && relative(got.DipoleMoment, tt.wantDipole) > tt.tolerance {
+				t.Errorf("DipoleMoment = %v, want %v", got.DipoleMoment, tt.wantDipole)
+			}
+			if tt.wantFrequency != 0 && relative(got.TransitionFrequency, tt.wantFrequency) > tt.tolerance {
+				t.Errorf("TransitionFrequency = %v, want %v", got.TransitionFrequency, tt.wantFrequency)
+			}
+			if relative(got.GFactor, tt.wantG) > tt.tolerance {
+				t.Errorf("GFactor = %v, want %v", got.GFactor, tt.wantG)
+			}
+		})
+	}
+}
+
+func TestApplySpecies(t *testing.T) {
+	caf, _ := LookupSpecies(SpeciesConfig{Name: "CaF"})
+	tests := []struct {
+		name                string
+		conf                PhysicsConfig
+		wantDipole          float64
+		wantField           float64
+		wantAtomDipoleUnset bool
+	}{
+		{
+			name:       "atomic units",
+			conf:       PhysicsConfig{Units: "atomic", BathSpecies: SpeciesConfig{Name: "CaF"}},
+			wantDipole: caf.DipoleMoment, wantField: caf.TransitionFrequency, wantAtomDipoleUnset: true,
+		},
+		{
+			name:       "overridden field",
+			conf:       PhysicsConfig{Units: "atomic", BathSpecies: SpeciesConfig{Name: "CaF"}, BathMagneticField: 1},
+			wantDipole: caf.DipoleMoment, wantField: 1, wantAtomDipoleUnset: true,
+		},
+		{
+			name: "declared units",
+			conf: PhysicsConfig{BathSpecies: SpeciesConfig{Name: "CaF"}, UnitSystem: UnitSystemConfig{Dipole: "ea0", Distance: "um", Field: "G", Frequency: "MHz"}},
+			// the field is the one that splits the levels by the transition frequency, turned back into MHz by applyUnitSystem
+			wantDipole: caf.DipoleMoment * debye / ea0, wantField: caf.TransitionFrequency / 1e6, wantAtomDipoleUnset: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := tt.conf
+			if err := applySpecies(&conf); err != nil {
+				t.Fatal(err)
+			}
+			if err := applyUnitSystem(&conf); err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(conf.BathDipoleMoment-tt.wantDipole) > 1e-9*tt.wantDipole {
+				t.Errorf("BathDipoleMoment = %v, want %v", conf.BathDipoleMoment, tt.wantDipole)
+			}
+			if math.Abs(conf.BathMagneticField-tt.wantField) > 1e-9*tt.wantField {
+				t.Errorf("BathMagneticField = %v, want %v", conf.BathMagneticField, tt.wantField)
+			}
+			if tt.wantAtomDipoleUnset && conf.AtomDipoleMoment != 0 {
+				t.Errorf("AtomDipoleMoment = %v, want it unset without an atom species", conf.AtomDipoleMoment)
+			}
+		})
+	}
+}
@@ -10,22 +10,100 @@ import (
 const e0 = 8.854e-12
 
 type System struct {
-	CentralSpin   State
-	Bath          []State
-	PhysicsConfig PhysicsConfig
-	DownSpins     int
+	CentralSpin      State
+	Bath             []State
+	PhysicsConfig    PhysicsConfig
+	DownSpins        int
+	QuantisationAxis Position // unit vector along the magnetic field, z when unset
 }
 
+// State is a site of the system. Angle (the cosine of the polar angle with respect to the quantisation axis) and Distance
+// are derived from Position, which is relative to the central atom
 type State struct {
 	Angle               float64
 	Distance            float64
 	InteractionStrength float64
+	Position            Position
 }
 
-type point struct {
-	x float64
-	y float64
-	z float64
+// Position is a point in Cartesian coordinates, relative to the central atom
+type Position struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+func (p Position) Norm() float64 {
+	return math.Sqrt(p.Dot(p))
+}
+
+func (p Position) Dot(q Position) float64 {
+	return p.X*q.X + p.Y*q.Y + p.Z*q.Z
+}
+
+func (p Position) Scale(f float64) Position {
+	return Position{X: f * p.X, Y: f * p.Y, Z: f * p.Z}
+}
+
+func (p Position) Add(q Position) Position {
+	return Position{X: p.X + q.X, Y: p.Y + q.Y, Z: p.Z + q.Z}
+}
+
+func (p Position) Sub(q Position) Position {
+	return p.Add(q.Scale(-1))
+}
+
+func (p Position) Cross(q Position) Position {
+	return Position{X: p.Y*q.Z - p.Z*q.Y, Y: p.Z*q.X - p.X*q.Z, Z: p.X*q.Y - p.Y*q.X}
+}
+
+// NewState places a site at 'position' and measures its angle from the unit vector 'axis'
+func NewState(position, axis Position) State {
+	r := position.Norm()
+	if r == 0 {
+		return State{Position: position}
+	}
+	return State{Angle: position.Dot(axis) / r, Distance: r, Position: position}
+}
+
+/*
+QuantisationAxis returns the direction of the magnetic field with respect to the geometry.
+In the laboratory frame the field points along Orientation.Field (polar angle and azimuth in units of π) if given,
+or else along z tilted by TiltAngle (in units of π) about the x axis. The geometry is turned by the rotation of Orientation,
+so in its own frame the field points along the inverse rotation of that direction. It panics on an invalid orientation
+*/
+func QuantisationAxis(conf PhysicsConfig) Position {
+	var field Position
+	switch len(conf.Orientation.Field) {
+	case 0:
+		tilt := conf.TiltAngle * math.Pi
+		field = Position{X: 0.0, Y: math.Sin(tilt), Z: math.Cos(tilt)}
+	case 2:
+		field = FieldDirection(conf.Orientation.Field[0], conf.Orientation.Field[1])
+	default:
+		panic(fmt.Sprintf("the field direction should be (polar, azimuth), got %v", conf.Orientation.Field))
+	}
+	rotation, err := conf.Orientation.Rotation()
+	if err != nil {
+		panic(err)
+	}
+	return rotation.Transpose().Apply(field)
+}
+
+func (s *System) axis() Position {
+	if s.QuantisationAxis == (Position{}) {
+		return Position{Z: 1.0}
+	}
+	return s.QuantisationAxis
+}
+
+// perpendicular returns a unit vector perpendicular to the unit vector 'axis'
+func perpendicular(axis Position) Position {
+	p := axis.Cross(Position{X: 1.0})
+	if p.Norm() < 1e-8 {
+		p = axis.Cross(Position{Y: 1.0})
+	}
+	return p.Scale(1 / p.Norm())
 }
 
 type Eigen struct {
@@ -36,45 +114,57 @@ type Eigen struct {
 func (s *System) DistanceGivenInteractionAt(j int) float64 {
 	rj := math.Pow(math.Abs(s.PhysicsConfig.BathDipoleMoment*s.PhysicsConfig.AtomDipoleMoment/(4*math.Pi*e0)*0.5/s.Bath[j-1].InteractionStrength*(1.0-3.0*math.Pow(s.Bath[j-1].Angle, 2))), 1.0/3.0)
 	s.Bath[j-1].Distance = rj
+
+	// keep the direction of the site, or place it in the plane of the quantisation axis at the given angle
+	if r := s.Bath[j-1].Position.Norm(); r > 0 {
+		s.Bath[j-1].Position = s.Bath[j-1].Position.Scale(rj / r)
+	} else {
+		s.Bath[j-1].Position = s.inAxisPlane(s.Bath[j-1].Angle, rj)
+	}
 	return rj
 }
 
+// inAxisPlane returns the point at distance r whose direction makes an angle of cosine 'cos' with the quantisation axis,
+// in the plane spanned by the axis and perpendicular(axis)
+func (s *System) inAxisPlane(cos, r float64) Position {
+	direction := s.axis().Scale(cos).Add(perpendicular(s.axis()).Scale(math.Sqrt(1 - cos*cos)))
+	return direction.Scale(r)
+}
+
+// SitePositions returns the positions of the bath sites. Sites known only by their distance and angle, as in results written
+// before positions were stored, are placed in the plane of the quantisation axis
+func (s *System) SitePositions() []Position {
+	positions := make([]Position, len(s.Bath))
+	for i, state := range s.Bath {
+		positions[i] = state.Position
+		if state.Position == (Position{}) && state.Distance != 0 {
+			positions[i] = s.inAxisPlane(state.Angle, state.Distance)
+		}
+	}
+	return positions
+}
+
+// PolarAngleCos returns the cosine of the angle between the j-th site of conf.Geometry and the quantisation axis
 func PolarAngleCos(j int, conf PhysicsConfig) float64 {
-	conf.TiltAngle *= math.Pi
-	if conf.Geometry == "ring" {
-		return math.Cos(float64(2*j)*math.Pi/float64(conf.BathCount)) * math.Sin(conf.TiltAngle)
-	} else if conf.Geometry == "cube" && j < 8 {
-		a := 1 / math.Sqrt(3.0)
-		v := []point{{a, a, a}, {-a, a, a}, {a, -a, a}, {-a, -a, a}, {a, a, -a}, {-a, a, -a}, {a, -a, -a}, {-a, -a, -a}}
-		return v[j].y*math.Sin(conf.TiltAngle) + v[j].z*math.Cos(conf.TiltAngle)
-	} else if conf.Geometry == "dodecahedron" && j < 20 {
-		a := 1 / math.Sqrt(3.0)
-		phi := (0.5 + math.Sqrt(5.0)*0.5) * a
-		iphi := 1.0 / phi * a
-		v := []point{{a, a, a}, {-a, a, a}, {a, -a, a}, {-a, -a, a},
-			{a, a, -a}, {-a, a, -a}, {a, -a, -a}, {-a, -a, -a},
-			{0.0, phi, iphi}, {0.0, -phi, iphi}, {0.0, phi, -iphi}, {0.0, -phi, -iphi},
-			{iphi, 0.0, phi}, {-iphi, 0.0, phi}, {iphi, 0.0, -phi}, {-iphi, 0.0, -phi},
-			{phi, iphi, 0.0}, {-phi, iphi, 0.0}, {phi, -iphi, 0.0}, {-phi, -iphi, 0.0}}
-		return v[j].y*math.Sin(conf.TiltAngle) + v[j].z*math.Cos(conf.TiltAngle)
-	} else if conf.Geometry == "icosahedron" && j < 12 {
-		/*
-			vertices calculated with mathematica
-			https://www.wolframcloud.com/obj/76badea4-ada5-4dc5-a415-8d6ea89de353
-		*/
-		v := []point{{0.0, 0.0, -1.0}, {0.0, 0.0, 1.0}, {-0.894427, 0.0, -0.447214}, {0.894427, 0.0, 0.447214}, {0.723607, -0.525731, -0.447214}, {0.723607, 0.525731, -0.447214}, {-0.723607, -0.525731, 0.447214}, {-0.723607, 0.525731, 0.447214}, {-0.276393, -0.850651, -0.447214}, {-0.276393, 0.850651, -0.447214}, {0.276393, -0.850651, 0.447214}, {0.276393, 0.850651, 0.447214}}
-		return v[j].y*math.Sin(conf.TiltAngle) + v[j].z*math.Cos(conf.TiltAngle)
-	} else if conf.Geometry == "sphere" { // https://stackoverflow.com/questions/9600801/evenly-distributing-n-points-on-a-sphere
-		bc := float64(conf.BathCount)
-		phi := math.Acos(1.0 - 2.0*(float64(j)+0.5)/bc)
-		theta := math.Pi * (1.0 + math.Sqrt(5.0)) * bc
-
-		y := math.Sin(theta) * math.Sin(phi)
-		z := math.Cos(phi)
-
-		return y*math.Sin(conf.TiltAngle) + z*math.Cos(conf.TiltAngle)
-	}
-	return 0.0
+	if conf.ConstantDistance == 0 {
+		conf.ConstantDistance = 1.0 // the angles of the polyhedra do not depend on their size
+	}
+	return BathStates(conf)[j].Angle
+}
+
+// BathStates places the bath according to the registered geometry conf.Geometry, with angles measured from QuantisationAxis(conf).
+// It panics if the geometry is unknown or does not hold BathCount sites
+func BathStates(conf PhysicsConfig) []State {
+	positions, err := GeometryPositions(conf)
+	if err != nil {
+		panic(err)
+	}
+	axis := QuantisationAxis(conf)
+	bath := make([]State, len(positions))
+	for i, p := range positions {
+		bath[i] = NewState(p, axis)
+	}
+	return bath
 }
 
 // InteractionAt returns the interaction strength between the j-th bath molecule and the central spin, given an index j
@@ -89,23 +179,21 @@ func (s *System) InteractionAt(j int) float64 {
 		return cj
 	}
 
-	var k float64
-	if s.PhysicsConfig.Units == "atomic" {
-		k = 149.42785955012954
-	} else { // SI
-		k = 1 / (4 * math.Pi * e0)
-	}
-
 	// Bath has indices 0:BathCount-1, and j has a range of 0:BathCount -> for j = 0 we mean the central spin which is not a part of the Bath.
 	// Therefore we pick Bath[j-1] instead of Bath[j]
-	c := k * (s.PhysicsConfig.BathDipoleMoment * s.PhysicsConfig.AtomDipoleMoment) / math.Pow(math.Abs(s.Bath[j-1].Distance), 3) *
-		(1.0 - 3.0*math.Pow(s.Bath[j-1].Angle, 2))
+	c := DipolarCoupling(s.PhysicsConfig, s.Bath[j-1])
 
 	// assign force value to bath state
 	s.Bath[j-1].InteractionStrength = c
 	return c
 }
 
+// DipolarCoupling returns the dipolar interaction strength between the central spin and a bath site in the state 'state'
+func DipolarCoupling(conf PhysicsConfig, state State) float64 {
+	return CouplingConstant(conf) * (conf.BathDipoleMoment * conf.AtomDipoleMoment) / math.Pow(math.Abs(state.Distance), 3) *
+		(1.0 - 3.0*math.Pow(state.Angle, 2))
+}
+
 // Given an index j, return the Heisenberg term (0, j - interaction) of the hamiltonian
 func (s *System) hamiltonianHeisenbergTermAt(j int) *mat.SymDense {
 	spin := s.PhysicsConfig.Spin
@@ -192,6 +280,35 @@ func (s *System) HamiltonianInBase(b0, b float64, indices []int) *mat.SymDense {
 	return mat.NewSymDense(dim, h.RawMatrix().Data)
 }
 
+// DownSpinCount counts the down spins of a ket such as "duuu", which fixes its magnetisation sector.
+// Kets with p or m slots span several sectors and are never restricted, so 0 is returned for them
+func DownSpinCount(ket string) int {
+	downSpins := 0
+	for s := range ket {
+		switch ket[s] {
+		case 'd':
+			downSpins++
+		case 'p', 'm':
+			return 0
+		}
+	}
+	return downSpins
+}
+
+// InitialKet returns the initial ket of the config, restricted to the sector of DownSpins if there is one
+func (s *System) InitialKet() *mat.VecDense {
+	fullKet := mat.NewVecDense(int(math.Pow(2*s.PhysicsConfig.Spin+1, float64(len(s.PhysicsConfig.InitialKet)))), ManyBodyVector(s.PhysicsConfig.InitialKet, int(2*s.PhysicsConfig.Spin+1)))
+	if s.DownSpins < 1 {
+		return fullKet
+	}
+	indices := BasisIndices(s.PhysicsConfig.BathCount+1, s.DownSpins)
+	ketData := make([]float64, len(indices))
+	for i, index := range indices {
+		ketData[i] = fullKet.AtVec(index)
+	}
+	return mat.NewVecDense(len(indices), ketData)
+}
+
 // Diagonalize returns eigenvectors and eigenvalues given a hamiltonian matrix
 func (s *System) Diagonalize(hamiltonian *mat.SymDense) Eigen {
 	var eig mat.EigenSym
@@ -3,6 +3,7 @@ package cs_q_sim
 import (
 	"math"
 	"reflect"
+	"strings"
 	"testing"
 
 	"gonum.org/v1/gonum/mat"
@@ -25,13 +26,13 @@ func TestSystem_InteractionAt(t *testing.T) {
 	}{
 		{
 			name:   "central",
-			fields: fields{CentralSpin: State{0.0, 1.0, 0.0}, Bath: []State{{0.0, 1.0, 0.0}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0}},
+			fields: fields{CentralSpin: State{0.0, 1.0, 0.0, Position{}}, Bath: []State{{0.0, 1.0, 0.0, Position{}}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0}},
 			args:   args{0},
 			want:   0.0,
 		},
 		{
 			name:   "test",
-			fields: fields{CentralSpin: State{0.0, 1.0, 0.0}, Bath: []State{{0.0, 1.0, 0.0}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0}},
+			fields: fields{CentralSpin: State{0.0, 1.0, 0.0, Position{}}, Bath: []State{{0.0, 1.0, 0.0, Position{}}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0}},
 			args:   args{1},
 			want:   0.98865,
 		},
@@ -67,7 +68,7 @@ func TestSystem_hamiltonianHeisenbergTermAt(t *testing.T) {
 	}{
 		{
 			name:   "first",
-			fields: fields{CentralSpin: State{0.0, 1.0, 0.0}, Bath: []State{{0.0, 1.0, 0.0}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5}},
+			fields: fields{CentralSpin: State{0.0, 1.0, 0.0, Position{}}, Bath: []State{{0.0, 1.0, 0.0, Position{}}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5}},
 			args:   args{1},
 			want: mat.NewDense(4, 4, []float64{
 				0.0, 0.0, 0.0, 0.0,
@@ -109,7 +110,7 @@ func TestSystem_hamiltonianMagneticTerm(t *testing.T) {
 	}{
 		{
 			name:   "test",
-			fields: fields{CentralSpin: State{0.0, 1.0, 0.0}, Bath: []State{{0.0, 1.0, 0.0}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5}},
+			fields: fields{CentralSpin: State{0.0, 1.0, 0.0, Position{}}, Bath: []State{{0.0, 1.0, 0.0, Position{}}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5}},
 			args:   args{b0: 1.0, b: 3.0},
 			want: mat.NewSymDense(4, []float64{
 				2.0, 0.0, 0.0, 0.0,
@@ -151,7 +152,7 @@ func TestSystem_hamiltonian(t *testing.T) {
 	}{
 		{
 			name:   "test",
-			fields: fields{CentralSpin: State{0.0, 1.0, 0.0}, Bath: []State{{0.0, 1.0, 0.0}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5}},
+			fields: fields{CentralSpin: State{0.0, 1.0, 0.0, Position{}}, Bath: []State{{0.0, 1.0, 0.0, Position{}}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5}},
 			args:   args{b0: 1.0, b: 3.0},
 			want: mat.NewDense(4, 4, []float64{
 				2.0, 0.0, 0.0, 0.0,
@@ -162,7 +163,7 @@ func TestSystem_hamiltonian(t *testing.T) {
 		},
 		{
 			name:   "bigger_system",
-			fields: fields{CentralSpin: State{0.0, 1.0, 0.0}, Bath: []State{{0.0, 1.0, 0.0}, {0.0, 2.0, 0.0}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5}},
+			fields: fields{CentralSpin: State{0.0, 1.0, 0.0, Position{}}, Bath: []State{{0.0, 1.0, 0.0, Position{}}, {0.0, 2.0, 0.0, Position{}}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5}},
 			args:   args{b0: 1.0, b: 3.0},
 			want: mat.NewDense(8, 8, []float64{
 				3.5, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0,
@@ -206,7 +207,7 @@ func TestSystem_diagonalize(t *testing.T) {
 	}{
 		{
 			name:   "2-body",
-			fields: fields{CentralSpin: State{0.0, 1.0, 0.0}, Bath: []State{{0.0, 1.0, 0.0}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5}},
+			fields: fields{CentralSpin: State{0.0, 1.0, 0.0, Position{}}, Bath: []State{{0.0, 1.0, 0.0, Position{}}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5}},
 			args: args{
 				hamiltonian: mat.NewSymDense(4, []float64{
 					-1.0, 0.0, 0.0, 0.0,
@@ -235,7 +236,7 @@ func TestSystem_diagonalize(t *testing.T) {
 		},
 		{
 			name:   "3-body",
-			fields: fields{CentralSpin: State{0.0, 1.0, 0.0}, Bath: []State{{0.0, 1.0, 0.0}, {0.0, 2.0, 0.0}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5}},
+			fields: fields{CentralSpin: State{0.0, 1.0, 0.0, Position{}}, Bath: []State{{0.0, 1.0, 0.0, Position{}}, {0.0, 2.0, 0.0, Position{}}}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5}},
 			args: args{
 				hamiltonian: mat.NewSymDense(8, []float64{
 					-1.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0,
@@ -291,8 +292,8 @@ func TestSystem_diagonalize_benchmark(t *testing.T) {
 	}{
 		{
 			name: "strain test",
-			fields: fields{CentralSpin: State{0.0, 1.0, 0.0}, Bath: []State{
-				{0.0, 1.0, 0.0}, {0.0, 2.0, 0.0}, {0.0, 1.1, 0.0}, {0.0, 1.2, 0.0}, {0.0, 1.3, 0.0},
+			fields: fields{CentralSpin: State{0.0, 1.0, 0.0, Position{}}, Bath: []State{
+				{0.0, 1.0, 0.0, Position{}}, {0.0, 2.0, 0.0, Position{}}, {0.0, 1.1, 0.0, Position{}}, {0.0, 1.2, 0.0, Position{}}, {0.0, 1.3, 0.0, Position{}},
 			}, PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5}},
 			args: args{
 				b0: 1.0,
@@ -336,8 +337,8 @@ func TestSystem_HamiltonianInBase(t *testing.T) {
 		{
 			name: "3 -> 1",
 			fields: fields{
-				CentralSpin:   State{0.0, 1.0, 0.0},
-				Bath:          []State{{0.0, 1.0, 0.0}, {0.0, 2.0, 0.0}},
+				CentralSpin:   State{0.0, 1.0, 0.0, Position{}},
+				Bath:          []State{{0.0, 1.0, 0.0, Position{}}, {0.0, 2.0, 0.0, Position{}}},
 				PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5},
 				DownSpins:     2,
 			},
@@ -384,8 +385,8 @@ func TestSystem_HamiltonianInBasePanic(t *testing.T) {
 		{
 			name: "Should panic 3 -> 0",
 			fields: fields{
-				CentralSpin:   State{0.0, 1.0, 0.0},
-				Bath:          []State{{0.0, 1.0, 0.0}, {0.0, 2.0, 0.0}},
+				CentralSpin:   State{0.0, 1.0, 0.0, Position{}},
+				Bath:          []State{{0.0, 1.0, 0.0, Position{}}, {0.0, 2.0, 0.0, Position{}}},
 				PhysicsConfig: PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, Spin: 0.5},
 				DownSpins:     2,
 			},
@@ -411,3 +412,143 @@ func TestSystem_HamiltonianInBasePanic(t *testing.T) {
 		_ = s.HamiltonianInBase(tt.args.b0, tt.args.b, tt.args.indices)
 	}
 }
+
+func TestBathStates(t *testing.T) {
+	for geometry, count := range map[string]int{"ring": 7, "cube": 8, "dodecahedron": 20, "icosahedron": 12, "sphere": 9} {
+		t.Run(geometry, func(t *testing.T) {
+			conf := PhysicsConfig{Geometry: geometry, BathCount: count, TiltAngle: 0.3, ConstantDistance: 1.5}
+			axis := QuantisationAxis(conf)
+			for i, state := range BathStates(conf) {
+				// the icosahedron vertices are tabulated to six digits
+				if math.Abs(state.Angle-PolarAngleCos(i, conf)) > 1e-6 {
+					t.Errorf("BathStates()[%v].Angle = %v, want %v", i, state.Angle, PolarAngleCos(i, conf))
+				}
+				if math.Abs(state.Position.Norm()-state.Distance) > 1e-12 || math.Abs(state.Distance-1.5) > 1e-6 {
+					t.Errorf("BathStates()[%v] = %v is not at the constant distance", i, state)
+				}
+				if math.Abs(state.Position.Dot(axis)/state.Distance-state.Angle) > 1e-12 {
+					t.Errorf("BathStates()[%v].Angle = %v does not follow from its position", i, state.Angle)
+				}
+			}
+		})
+	}
+}
+
+func TestSystem_DistanceGivenInteractionAt(t *testing.T) {
+	conf := PhysicsConfig{BathDipoleMoment: 1.1e-10, AtomDipoleMoment: 1.0, InteractionCoefficients: []float64{0.0, 0.5, -0.2}}
+	s := &System{
+		Bath:             []State{{Angle: 0.5}, NewState(Position{X: 3.0, Z: 4.0}, Position{Z: 1.0})},
+		PhysicsConfig:    conf,
+		QuantisationAxis: Position{Z: 1.0},
+	}
+	for j := 1; j <= 2; j++ {
+		s.InteractionAt(j)
+		r := s.DistanceGivenInteractionAt(j)
+		state := s.Bath[j-1]
+		if math.Abs(state.Position.Norm()-r) > 1e-12*r || math.Abs(state.Position.Z/r-state.Angle) > 1e-12 {
+			t.Errorf("DistanceGivenInteractionAt(%v) left the site at %v, want distance %v and angle %v", j, state.Position, r, state.Angle)
+		}
+	}
+	if direction := s.Bath[1].Position.Scale(1 / s.Bath[1].Distance); math.Abs(direction.X-0.6) > 1e-12 {
+		t.Errorf("DistanceGivenInteractionAt() changed the direction of the site to %v", direction)
+	}
+}
+
+func TestSystem_EvolutionInSector(t *testing.T) {
+	tests := []struct {
+		name          string
+		physicsConfig PhysicsConfig
+		ket           string
+		times         []float64
+	}{
+		{
+			name:          "single down spin",
+			physicsConfig: PhysicsConfig{Spin: 0.5, InteractionCoefficients: []float64{0.0, 1.0, 0.5, -0.3}},
+			ket:           "duuu",
+			times:         []float64{0.0, 0.7, 1.9, 4.2},
+		},
+		{
+			name:          "two down spins, XXX",
+			physicsConfig: PhysicsConfig{Spin: 0.5, Model: "XXX", InteractionCoefficients: []float64{0.0, 1.0, 0.5, -0.3}},
+			ket:           "dduu",
+			times:         []float64{0.0, 0.7, 1.9, 4.2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b0, b := 1.3, 1.0
+			s := &System{
+				Bath:          make([]State, len(tt.ket)-1),
+				PhysicsConfig: tt.physicsConfig,
+			}
+			downSpins := strings.Count(tt.ket, "d")
+			indices := BasisIndices(len(tt.ket), downSpins)
+			fullKet := mat.NewVecDense(1<<len(tt.ket), ManyBodyVector(tt.ket, 2))
+			sectorData := make([]float64, len(indices))
+			for i, index := range indices {
+				sectorData[i] = fullKet.AtVec(index)
+			}
+			sectorKet := mat.NewVecDense(len(indices), sectorData)
+
+			fullEigen := s.Diagonalize(s.Hamiltonian(b0, b))
+			fullGram := Grammian(fullKet, fullEigen.EigenVectors)
+			sectorEigen := s.Diagonalize(s.HamiltonianInBase(b0, b, indices))
+			sectorGram := Grammian(sectorKet, sectorEigen.EigenVectors)
+			fullObservable := ManyBodyOperator(Sz(0.5), 0, len(tt.ket))
+			sectorObservable := Observable{Dense: *RestrictMatrixToSubspace(fullObservable, indices)}
+
+			for _, time := range tt.times {
+				full := (&Observable{Dense: *fullObservable}).ExpectationValue(Evolve(fullKet, time, fullEigen.EigenValues, fullEigen.EigenVectors, fullGram))
+				sector := sectorObservable.ExpectationValue(Evolve(sectorKet, time, sectorEigen.EigenValues, sectorEigen.EigenVectors, sectorGram))
+				if math.Abs(full-sector) > 1e-10 {
+					t.Errorf("t = %v: <Sz> in the sector = %v, in the full space = %v", time, sector, full)
+				}
+			}
+		})
+	}
+}
+
+func TestDownSpinCount(t *testing.T) {
+	tests := []struct {
+		ket  string
+		want int
+	}{
+		{ket: "uuuu", want: 0},
+		{ket: "duud", want: 2},
+		{ket: "pdud", want: 0},
+		{ket: "ddum", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ket, func(t *testing.T) {
+			if got := DownSpinCount(tt.ket); got != tt.want {
+				t.Errorf("DownSpinCount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSystem_InitialKet(t *testing.T) {
+	tests := []struct {
+		ket     string
+		wantDim int
+	}{
+		{ket: "duud", wantDim: 6},
+		{ket: "pdud", wantDim: 16},
+		{ket: "mduu", wantDim: 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ket, func(t *testing.T) {
+			s := &System{
+				PhysicsConfig: PhysicsConfig{Spin: 0.5, InitialKet: tt.ket, BathCount: len(tt.ket) - 1},
+				DownSpins:     DownSpinCount(tt.ket),
+			}
+			got := s.InitialKet()
+			if got.Len() != tt.wantDim {
+				t.Errorf("System.InitialKet() has dimension %v, want %v", got.Len(), tt.wantDim)
+			}
+			if norm := mat.Norm(got, 2); math.Abs(norm-1) > 1e-12 {
+				t.Errorf("System.InitialKet() has norm %v, want 1", norm)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package cs_q_sim
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Dimension is the physical dimension of a unit
+type Dimension int
+
+const (
+	DipoleDimension Dimension = iota
+	LengthDimension
+	FrequencyDimension
+	MagneticFieldDimension
+	TimeDimension
+)
+
+func (d Dimension) String() string {
+	return [...]string{"dipole moment", "length", "frequency", "magnetic field", "time"}[d]
+}
+
+// Unit is a named unit of a given dimension, SI units of it long
+type Unit struct {
+	Symbol    string
+	Dimension Dimension
+	SI        float64
+}
+
+const (
+	planck             = 6.62607015e-34 // J s
+	vacuumPermittivity = 8.8541878128e-12
+	debye              = 3.33564095198152e-30
+	ea0                = 8.4783536255e-30
+	// ElectronGyromagneticRatio is g μB / h of the free electron, in Hz/T
+	ElectronGyromagneticRatio = 28.02495142e9
+)
+
+var dipoleUnits = map[string]float64{
+	"d":     debye,
+	"debye": debye,
+	"ea0":   ea0,
+	"au":    ea0,
+	"c m":   1.0,
+	"c·m":   1.0,
+}
+
+var frequencyUnits = map[string]float64{
+	"hz":  1.0,
+	"khz": 1e3,
+	"mhz": 1e6,
+	"ghz": 1e9,
+}
+
+var magneticFieldUnits = map[string]float64{
+	"t":  1.0,
+	"mt": 1e-3,
+	"g":  1e-4,
+	"mg": 1e-7,
+}
+
+var timeUnits = map[string]float64{
+	"s":  1.0,
+	"ms": 1e-3,
+	"us": 1e-6,
+	"µs": 1e-6,
+	"ns": 1e-9,
+}
+
+// reciprocalTimeUnits names the time unit 1/f of each frequency unit f
+var reciprocalTimeUnits = map[string]string{"hz": "s", "khz": "ms", "mhz": "us", "ghz": "ns"}
+
+// ParseUnit looks up the unit 'symbol' (case-insensitive) of the dimension 'dim'
+func ParseUnit(symbol string, dim Dimension) (Unit, error) {
+	tables := map[Dimension]map[string]float64{
+		DipoleDimension:        dipoleUnits,
+		LengthDimension:        lengthUnits,
+		FrequencyDimension:     frequencyUnits,
+		MagneticFieldDimension: magneticFieldUnits,
+		TimeDimension:          timeUnits,
+	}
+	if si, ok := tables[dim][strings.ToLower(strings.TrimSpace(symbol))]; ok {
+		return Unit{Symbol: symbol, Dimension: dim, SI: si}, nil
+	}
+	return Unit{}, fmt.Errorf("unknown %v unit %q", dim, symbol)
+}
+
+// Convert expresses 'value' given in the unit 'from' in the unit 'to' of the same dimension
+func Convert(value float64, from, to Unit) (float64, error) {
+	if from.Dimension != to.Dimension {
+		return 0, fmt.Errorf("cannot convert %v (%v) to %v (%v)", from.Symbol, from.Dimension, to.Symbol, to.Dimension)
+	}
+	return value * from.SI / to.SI, nil
+}
+
+// declared reports whether the config declares its units through UnitSystem rather than the legacy Units switch
+func (us UnitSystemConfig) declared() bool {
+	return us.Frequency != ""
+}
+
+// fieldUnit parses the unit of the magnetic fields, which is either a frequency unit or a magnetic field unit
+func (us UnitSystemConfig) fieldUnit() (Unit, error) {
+	if u, err := ParseUnit(us.Field, FrequencyDimension); err == nil {
+		return u, nil
+	}
+	return ParseUnit(us.Field, MagneticFieldDimension)
+}
+
+// fieldFrequency converts a field in the declared field unit to the internal frequency unit, using the gyromagnetic ratio gamma (Hz/T)
+// for fields given in tesla or gauss
+func (us UnitSystemConfig) fieldFrequency(value, gamma float64) (float64, error) {
+	frequency, err := ParseUnit(us.Frequency, FrequencyDimension)
+	if err != nil {
+		return 0, err
+	}
+	field, err := us.fieldUnit()
+	if err != nil {
+		return 0, err
+	}
+	if field.Dimension == MagneticFieldDimension {
+		if gamma == 0 {
+			gamma = ElectronGyromagneticRatio
+		}
+		return value * field.SI * gamma / frequency.SI, nil
+	}
+	return Convert(value, field, frequency)
+}
+
+/*
+applyUnitSystem brings a config that declares its UnitSystem to the internal units: the magnetic fields to the frequency unit and Dt
+to its reciprocal time unit. The declaration is updated to match, so the stored config stays consistent and converting it again changes nothing.
+Dipole moments and distances stay in their declared units and enter through CouplingConstant
+*/
+func applyUnitSystem(conf *PhysicsConfig) error {
+	us := conf.UnitSystem
+	if !us.declared() {
+		if us != (UnitSystemConfig{}) {
+			return fmt.Errorf("the unit system should declare the internal frequency unit")
+		}
+		return nil
+	}
+	if conf.Units != "" {
+		return fmt.Errorf("units %q and a declared unit system exclude each other", conf.Units)
+	}
+	frequency, err := ParseUnit(us.Frequency, FrequencyDimension)
+	if err != nil {
+		return err
+	}
+	for _, check := range []struct {
+		symbol string
+		dim    Dimension
+	}{{us.Dipole, DipoleDimension}, {us.Distance, LengthDimension}} {
+		if _, err := ParseUnit(check.symbol, check.dim); err != nil {
+			return err
+		}
+	}
+
+	if us.Field != "" {
+		if conf.BathMagneticField, err = us.fieldFrequency(conf.BathMagneticField, us.BathGyromagneticRatio); err != nil {
+			return err
+		}
+		if conf.CentralMagneticField, err = us.fieldFrequency(conf.CentralMagneticField, us.CentralGyromagneticRatio); err != nil {
+			return err
+		}
+	}
+	internalTime := reciprocalTimeUnits[strings.ToLower(frequency.Symbol)]
+	if us.Time != "" {
+		from, err := ParseUnit(us.Time, TimeDimension)
+		if err != nil {
+			return err
+		}
+		to, _ := ParseUnit(internalTime, TimeDimension)
+		conf.Dt, _ = Convert(conf.Dt, from, to)
+	}
+
+	conf.UnitSystem.Field = us.Frequency
+	conf.UnitSystem.Time = internalTime
+	return nil
+}
+
+/*
+CouplingConstant returns k of the dipolar coupling k·d1·d2·(1 - 3cos²θ)/r³. With a declared UnitSystem, k = 1/(4πε0 h) in the
+declared dipole, distance and frequency units. Otherwise atomic units take the legacy constant 149.42785955012954 (dipoles in D,
+distances in µm), kept so that existing results reproduce although it is about 1% below the declared D, µm and Hz system,
+and any other value of Units gives SI energies, 1/(4πε0)
+*/
+func CouplingConstant(conf PhysicsConfig) float64 {
+	if us := conf.UnitSystem; us.declared() {
+		dipole, err := ParseUnit(us.Dipole, DipoleDimension)
+		if err != nil {
+			panic(err)
+		}
+		distance, err := ParseUnit(us.Distance, LengthDimension)
+		if err != nil {
+			panic(err)
+		}
+		frequency, err := ParseUnit(us.Frequency, FrequencyDimension)
+		if err != nil {
+			panic(err)
+		}
+		return dipole.SI * dipole.SI / (4 * math.Pi * vacuumPermittivity * math.Pow(distance.SI, 3) * planck * frequency.SI)
+	}
+	if conf.Units == "atomic" {
+		return 149.42785955012954
+	}
+	return 1 / (4 * math.Pi * e0)
+}
+
+// OutputUnits names the units of the quantities in the results of a simulation run with the config.
+// SI energies come with times in units of ħ/J, as the evolution takes exp(-iHt)
+func OutputUnits(conf PhysicsConfig) map[string]string {
+	if us := conf.UnitSystem; us.declared() {
+		return map[string]string{
+			"dipole":   us.Dipole,
+			"distance": us.Distance,
+			"coupling": us.Frequency,
+			"field":    us.Frequency,
+			"time":     reciprocalTimeUnits[strings.ToLower(us.Frequency)],
+		}
+	}
+	if conf.Units == "atomic" {
+		return map[string]string{"dipole": "D", "distance": "um", "coupling": "Hz", "field": "Hz", "time": "s"}
+	}
+	return map[string]string{"dipole": "C m", "distance": "m", "coupling": "J", "field": "J", "time": "hbar/J"}
+}
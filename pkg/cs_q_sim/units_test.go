@@ -0,0 +1,103 @@
+package cs_q_sim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    float64
+		from, to string
+		dims     [2]Dimension
+		want     float64
+		wantErr  bool
+	}{
+		{name: "debye to ea0", value: 2.541746473, from: "D", to: "ea0", dims: [2]Dimension{DipoleDimension, DipoleDimension}, want: 1},
+		{name: "um to a0", value: 1, from: "um", to: "a0", dims: [2]Dimension{LengthDimension, LengthDimension}, want: 18897.26124565},
+		{name: "MHz to Hz", value: 2.5, from: "MHz", to: "Hz", dims: [2]Dimension{FrequencyDimension, FrequencyDimension}, want: 2.5e6},
+		{name: "gauss to tesla", value: 1, from: "G", to: "T", dims: [2]Dimension{MagneticFieldDimension, MagneticFieldDimension}, want: 1e-4},
+		{name: "dimension mismatch", value: 1, from: "us", to: "Hz", dims: [2]Dimension{TimeDimension, FrequencyDimension}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, err := ParseUnit(tt.from, tt.dims[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			to, err := ParseUnit(tt.to, tt.dims[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Convert(tt.value, from, to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Convert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-8*math.Abs(tt.want) {
+				t.Errorf("Convert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if _, err := ParseUnit("um", DipoleDimension); err == nil {
+		t.Errorf("ParseUnit() should reject a length unit for a dipole moment")
+	}
+}
+
+func TestCouplingConstant(t *testing.T) {
+	tests := []struct {
+		name string
+		conf PhysicsConfig
+		want float64
+	}{
+		{name: "atomic", conf: PhysicsConfig{Units: "atomic"}, want: 149.42785955012954},
+		{name: "SI", conf: PhysicsConfig{}, want: 1 / (4 * math.Pi * e0)},
+		// 1 D² / (4πε0) is 1e-49 J m³ up to the definition of the debye
+		{name: "debye, um, Hz", conf: PhysicsConfig{UnitSystem: UnitSystemConfig{Dipole: "D", Distance: "um", Frequency: "Hz"}}, want: 1e-31 / planck},
+		{name: "debye, um, kHz", conf: PhysicsConfig{UnitSystem: UnitSystemConfig{Dipole: "debye", Distance: "µm", Frequency: "kHz"}}, want: 1e-34 / planck},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CouplingConstant(tt.conf); math.Abs(got-tt.want) > 1e-6*tt.want {
+				t.Errorf("CouplingConstant() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyUnitSystem(t *testing.T) {
+	us := UnitSystemConfig{Dipole: "D", Distance: "um", Field: "G", Time: "ms", Frequency: "MHz"}
+	conf := PhysicsConfig{UnitSystem: us, BathMagneticField: 10, CentralMagneticField: 1, Dt: 2}
+	conf.UnitSystem.CentralGyromagneticRatio = 1e6
+	if err := applyUnitSystem(&conf); err != nil {
+		t.Fatal(err)
+	}
+	// 10 G of the free electron is 28.02 MHz, 1 G at 1 MHz/T is 1e-4 MHz, and 2 ms is 2000 us
+	if math.Abs(conf.BathMagneticField-28.02495142) > 1e-9 || math.Abs(conf.CentralMagneticField-1e-4) > 1e-15 || math.Abs(conf.Dt-2000) > 1e-9 {
+		t.Errorf("applyUnitSystem() gave fields %v, %v and dt %v", conf.BathMagneticField, conf.CentralMagneticField, conf.Dt)
+	}
+	if conf.UnitSystem.Field != "MHz" || conf.UnitSystem.Time != "us" {
+		t.Errorf("applyUnitSystem() should declare the internal units, got %+v", conf.UnitSystem)
+	}
+	converted := conf
+	if err := applyUnitSystem(&conf); err != nil || conf.BathMagneticField != converted.BathMagneticField || conf.Dt != converted.Dt {
+		t.Errorf("applying the unit system twice should change nothing, got %+v", conf)
+	}
+	want := map[string]string{"dipole": "D", "distance": "um", "coupling": "MHz", "field": "MHz", "time": "us"}
+	for quantity, unit := range OutputUnits(conf) {
+		if want[quantity] != unit {
+			t.Errorf("OutputUnits()[%v] = %v, want %v", quantity, unit, want[quantity])
+		}
+	}
+
+	for _, bad := range []PhysicsConfig{
+		{Units: "atomic", UnitSystem: UnitSystemConfig{Dipole: "D", Distance: "um", Frequency: "Hz"}},
+		{UnitSystem: UnitSystemConfig{Dipole: "D", Distance: "um"}},
+		{UnitSystem: UnitSystemConfig{Dipole: "D", Distance: "um", Frequency: "Hz", Field: "V"}},
+		{UnitSystem: UnitSystemConfig{Dipole: "um", Distance: "um", Frequency: "Hz"}},
+	} {
+		if err := applyUnitSystem(&bad); err == nil {
+			t.Errorf("applyUnitSystem(%+v) should fail", bad.UnitSystem)
+		}
+	}
+}
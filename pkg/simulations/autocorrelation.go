@@ -0,0 +1,100 @@
+package simulations
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	cs "github.com/korsakjakub/cs_q_sim/pkg/cs_q_sim"
+	"gonum.org/v1/plot/plotter"
+)
+
+// Autocorrelation computes <A(t) B(0)> of the initial ket and its broadened spectral function from the Lehmann representation,
+// so neither the time series nor the spectrum needs sampling or FFTs
+func Autocorrelation(conf cs.Config) {
+	conf.Physics.BathCount = len(conf.Physics.InitialKet) - 1
+	spectral := conf.Physics.Spectral
+	if len(spectral.Operators) == 0 {
+		spectral.Operators = []string{"Sp", "Sm"}
+	}
+	if len(spectral.Operators) != 2 || len(spectral.FrequencyRange) != 2 {
+		panic("spectral needs two operators and a frequency range (min, max)")
+	}
+	if spectral.FrequencyPoints < 2 {
+		panic("spectral needs at least two frequencypoints to span the frequency range")
+	}
+	if spectral.Broadening != "lorentzian" && spectral.Broadening != "gaussian" {
+		panic("unknown broadening: " + spectral.Broadening)
+	}
+	if spectral.Width <= 0 {
+		panic("spectral needs a positive width for the broadening")
+	}
+	start := time.Now()
+	startTime := start.Format(time.RFC3339)
+
+	var bath []cs.State
+	if len(conf.Physics.InteractionCoefficients) == 0 {
+		bath = cs.BathStates(conf.Physics)
+	} else {
+		bath = make([]cs.State, conf.Physics.BathCount)
+	}
+
+	// A and B need not conserve the magnetisation, so the full Hilbert space is used
+	s := &cs.System{
+		CentralSpin:      cs.State{Angle: 0.0, Distance: 0.0},
+		Bath:             bath,
+		PhysicsConfig:    conf.Physics,
+		QuantisationAxis: cs.QuantisationAxis(conf.Physics),
+	}
+	initialKet := s.InitialKet()
+	fmt.Println("Diagonalizing...")
+	eigen := solveEigenProblem(s)
+
+	sites := conf.Physics.BathCount + 1
+	a := cs.ManyBodyOperator(spinOperator(spectral.Operators[0], conf.Physics.Spin), spectral.Slot, sites)
+	b := cs.ManyBodyOperator(spinOperator(spectral.Operators[1], conf.Physics.Spin), spectral.Slot, sites)
+	peaks := cs.LehmannCorrelation(a, b, initialKet, eigen)
+	if conf.Verbosity == "debug" {
+		fmt.Printf("%v Lehmann peaks\n", len(peaks.Frequencies))
+	}
+
+	var re, im, spectrum plotter.XYs
+	for t := 0; t < conf.Physics.TimeRange; t++ {
+		evolutionTime := conf.Physics.Dt * float64(t)
+		c := peaks.At(evolutionTime)
+		re = append(re, plotter.XY{X: evolutionTime / (2.0 * math.Pi), Y: real(c)})
+		im = append(im, plotter.XY{X: evolutionTime / (2.0 * math.Pi), Y: imag(c)})
+	}
+	minOmega, maxOmega := spectral.FrequencyRange[0], spectral.FrequencyRange[1]
+	for i := 0; i < spectral.FrequencyPoints; i++ {
+		omega := minOmega + (maxOmega-minOmega)*float64(i)/float64(spectral.FrequencyPoints-1)
+		spectrum = append(spectrum, plotter.XY{X: omega, Y: peaks.Spectral(omega, spectral.Width, spectral.Broadening)})
+	}
+
+	correlator := fmt.Sprintf("<%v_%v(t) %v_%v(0)>", spectral.Operators[0], spectral.Slot, spectral.Operators[1], spectral.Slot)
+	elapsedTime := time.Since(start)
+	r := cs.ResultsIO{
+		Filename: startTime,
+		Metadata: cs.Metadata{
+			Date:           startTime,
+			Simulation:     "Autocorrelation and spectral function",
+			SimulationId:   conf.Simulation,
+			Cpu:            conf.Files.ResultsConfig.Cpu,
+			Ram:            conf.Files.ResultsConfig.Ram,
+			CompletionTime: elapsedTime.String(),
+			FiguresDir:     conf.Files.FigDir,
+		},
+		Values: struct {
+			System cs.System "mapstructure:\"system\""
+		}{
+			System: *s,
+		},
+		XYs:    []plotter.XYs{re, im, spectrum},
+		Labels: []string{"Re " + correlator, "Im " + correlator, fmt.Sprintf("%v spectral function (%v, width %v)", spectral.Broadening, correlator, spectral.Width)},
+		Scalars: map[string]float64{
+			"peaks":        float64(len(peaks.Frequencies)),
+			"spectral sum": real(peaks.At(0)),
+		},
+	}
+	r.Write(conf.Files)
+}
@@ -0,0 +1,84 @@
+package simulations
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	cs "github.com/korsakjakub/cs_q_sim/pkg/cs_q_sim"
+	"gonum.org/v1/plot/plotter"
+)
+
+// DecayTimeVsOrientation maps the decay time over the field directions of OrientationSweep.
+// Every polar angle gives one curve of the decay time against the azimuth, both in units of π, for the geometry turned by Orientation
+func DecayTimeVsOrientation(conf cs.Config) {
+	start := time.Now()
+
+	sc := conf.Physics.OrientationSweep
+	polars := sweepValues(sc.PolarRange, sc.PolarSteps, "PolarRange")
+	azimuths := sweepValues(sc.AzimuthRange, sc.AzimuthSteps, "AzimuthRange")
+
+	var xys []plotter.XYs
+	var labels []string
+	best := map[string]float64{"max decay time": math.Inf(-1), "min decay time": math.Inf(1)}
+	for _, polar := range polars {
+		var curve plotter.XYs
+		for _, azimuth := range azimuths {
+			sampled := conf
+			sampled.Physics.Orientation.Field = []float64{polar, azimuth}
+			t := decayTime(spread(prepareStates(sampled)))
+			curve = append(curve, plotter.XY{X: azimuth, Y: t})
+			if t > best["max decay time"] {
+				best["max decay time"], best["max polar"], best["max azimuth"] = t, polar, azimuth
+			}
+			if t < best["min decay time"] {
+				best["min decay time"], best["min polar"], best["min azimuth"] = t, polar, azimuth
+			}
+		}
+		xys = append(xys, curve)
+		labels = append(labels, fmt.Sprintf("polar=%v", polar))
+	}
+
+	start_time := start.Format(time.RFC3339)
+
+	elapsed_time := time.Since(start)
+	r := cs.ResultsIO{
+		Filename: start_time,
+		Metadata: cs.Metadata{
+			Date:           start_time,
+			Simulation:     "Decay time vs orientation",
+			SimulationId:   conf.Simulation,
+			Cpu:            conf.Files.ResultsConfig.Cpu,
+			Ram:            conf.Files.ResultsConfig.Ram,
+			CompletionTime: elapsed_time.String(),
+			FiguresDir:     conf.Files.FigDir,
+		},
+		Values: struct {
+			System cs.System "mapstructure:\"system\""
+		}{
+			System: cs.System{PhysicsConfig: conf.Physics},
+		},
+		XYs:     xys,
+		Labels:  labels,
+		Scalars: best,
+	}
+	r.Write(conf.Files)
+}
+
+// sweepValues returns 'steps' evenly spaced values from min to max inclusive, or just min for a single step
+func sweepValues(bounds []float64, steps int, name string) []float64 {
+	if len(bounds) != 2 {
+		panic(name + " should have length 2. (min, max)")
+	}
+	if steps < 1 {
+		steps = 1
+	}
+	values := make([]float64, steps)
+	for i := range values {
+		values[i] = bounds[0]
+		if steps > 1 {
+			values[i] += (bounds[1] - bounds[0]) * float64(i) / float64(steps-1)
+		}
+	}
+	return values
+}
@@ -14,6 +14,7 @@ func DecayTimeVsTiltAngle(conf cs.Config) {
 	if len(conf.Physics.TiltAngleRange) != 2 {
 		panic("TiltAngleRange should have length 2. (min, max)")
 	}
+	requireTilt(conf.Physics, "a tilt angle sweep")
 	minTiltAngle := conf.Physics.TiltAngleRange[0]
 	maxTiltAngle := conf.Physics.TiltAngleRange[1]
 
@@ -24,12 +25,8 @@ func DecayTimeVsTiltAngle(conf cs.Config) {
 		conf.Physics.TiltAngle = tiltAngle
 		states := prepareStates(conf)
 		spread := spread(states)
-		if spread < 1e-8 {
-			spread = 1e-8
-		}
 		fmt.Println(spread)
-		decayTime := 1e-4 / (spread * 1e-3)
-		xys = append(xys, plotter.XY{X: tiltAngle, Y: decayTime})
+		xys = append(xys, plotter.XY{X: tiltAngle, Y: decayTime(spread)})
 
 		tiltAngle += conf.Physics.Dt
 	}
@@ -56,3 +53,11 @@ func DecayTimeVsTiltAngle(conf cs.Config) {
 	}
 	r.Write(conf.Files)
 }
+
+// decayTime estimates the decay time from the spread of the couplings, capped where the couplings are all equal
+func decayTime(spread float64) float64 {
+	if spread < 1e-8 {
+		spread = 1e-8
+	}
+	return 1e-4 / (spread * 1e-3)
+}
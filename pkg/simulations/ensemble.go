@@ -0,0 +1,86 @@
+package simulations
+
+import (
+	"fmt"
+	"time"
+
+	cs "github.com/korsakjakub/cs_q_sim/pkg/cs_q_sim"
+	"gonum.org/v1/plot/plotter"
+)
+
+// Ensemble repeats the spin evolution or the coupling-spread scan over disorder realisations of the bath
+// and writes the mean, the standard deviation and the standard error of every curve
+func Ensemble(conf cs.Config) {
+	ec := conf.Physics.Ensemble
+	start := time.Now()
+	startTime := start.Format(time.RFC3339)
+
+	seeds := ec.Seeds
+	if len(seeds) == 0 {
+		for i := 0; i < ec.Realisations; i++ {
+			seeds = append(seeds, conf.Physics.Disorder.Seed+int64(i))
+		}
+	}
+	if len(seeds) == 0 {
+		panic("an ensemble needs at least one realisation or seed")
+	}
+	conf.Physics.Ensemble.Seeds = seeds
+
+	var curves [][]plotter.XYs // curves[k][i] is the k-th curve of the i-th realisation
+	var labels []string
+	for i, seed := range seeds {
+		fmt.Printf("Realisation %v/%v (seed %v)\n", i+1, len(seeds), seed)
+		realisation := conf
+		realisation.Physics.Disorder.Seed = seed
+		realisation.Physics.Lattice.Seed = seed
+		// every realisation has its own Hamiltonian, so none of them can be loaded from a stored diagonalisation
+		realisation.Files.DiagonalizationDir = ""
+
+		var xyss []plotter.XYs
+		switch ec.Simulation {
+		case "spin-evolution":
+			_, xyss, labels, _ = spinTimeEvolution(realisation, "")
+		case "spread-of-couplings":
+			xyss, labels = []plotter.XYs{spreadCurve(realisation)}, []string{"spread"}
+		default:
+			panic("unknown ensemble simulation: " + ec.Simulation)
+		}
+		if curves == nil {
+			curves = make([][]plotter.XYs, len(xyss))
+		}
+		for k := range xyss {
+			curves[k] = append(curves[k], xyss[k])
+		}
+	}
+
+	var xyss []plotter.XYs
+	var statLabels []string
+	for k, realisations := range curves {
+		mean, std, stderr := cs.CurveStatistics(realisations)
+		xyss = append(xyss, mean, std, stderr)
+		statLabels = append(statLabels, "mean "+labels[k], "std "+labels[k], "stderr "+labels[k])
+	}
+
+	elapsedTime := time.Since(start)
+	r := cs.ResultsIO{
+		Filename: startTime,
+		Metadata: cs.Metadata{
+			Date:           startTime,
+			Simulation:     "Disorder ensemble of " + ec.Simulation,
+			SimulationId:   conf.Simulation,
+			Cpu:            conf.Files.ResultsConfig.Cpu,
+			Ram:            conf.Files.ResultsConfig.Ram,
+			CompletionTime: elapsedTime.String(),
+			FiguresDir:     conf.Files.FigDir,
+		},
+		Values: struct {
+			System cs.System "mapstructure:\"system\""
+		}{
+			System: cs.System{PhysicsConfig: conf.Physics},
+		},
+		XYs:     xyss,
+		Labels:  statLabels,
+		Scalars: map[string]float64{"realisations": float64(len(seeds))},
+	}
+	r.Write(conf.Files)
+}
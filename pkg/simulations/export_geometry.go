@@ -0,0 +1,151 @@
+package simulations
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"sort"
+
+	cs "github.com/korsakjakub/cs_q_sim/pkg/cs_q_sim"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// ExportGeometry renders the central atom and the bath sites stored in the results file conf.Export.Results as a projected 3D scatter,
+// with the sites coloured by their coupling, and writes them as .xyz and .obj files for external viewers
+func ExportGeometry(conf cs.Config) {
+	ec := conf.Export
+	r := cs.Read(conf.Files, ec.Results)
+	s := &r.Values.System
+	unit := cs.DistanceUnit(s.PhysicsConfig)
+
+	formats := ec.Formats
+	if len(formats) == 0 {
+		formats = []string{"png", "svg", "xyz", "obj"}
+	}
+	for _, format := range formats {
+		path := fmt.Sprintf("%v%v-geometry.%v", conf.Files.FigDir, ec.Results, format)
+		var err error
+		switch format {
+		case "xyz", "obj":
+			err = writeGeometryFile(path, s, unit, format)
+		default:
+			err = renderGeometry(path, s, unit, format, ec.View, r.Metadata.Simulation)
+		}
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("File created:\n%v\n", path)
+	}
+}
+
+func writeGeometryFile(path string, s *cs.System, unit, format string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if format == "xyz" {
+		return cs.WriteXYZ(f, s, unit)
+	}
+	return cs.WriteOBJ(f, s, unit)
+}
+
+// projectedSite is a site seen from the view direction: its screen coordinates, its depth towards the viewer and its colour
+type projectedSite struct {
+	x, y, depth float64
+	color       color.Color
+	central     bool
+}
+
+// renderGeometry draws the sites from the view (azimuth, elevation) in units of π, the nearer ones larger and on top,
+// along with the quantisation axis and a colour bar of the couplings
+func renderGeometry(path string, s *cs.System, unit, format string, view []float64, title string) error {
+	azimuth, elevation := 0.25, 0.1
+	if len(view) == 2 {
+		azimuth, elevation = view[0], view[1]
+	}
+	phi, theta := azimuth*math.Pi, elevation*math.Pi
+	toViewer := cs.Position{X: math.Cos(theta) * math.Cos(phi), Y: math.Cos(theta) * math.Sin(phi), Z: math.Sin(theta)}
+	right := cs.Position{X: -math.Sin(phi), Y: math.Cos(phi)}
+	up := toViewer.Cross(right)
+
+	positions := s.SitePositions()
+	cm := cs.CouplingColorMap(s)
+	sites := []projectedSite{{color: color.Black, central: true}}
+	extent := 0.0
+	for i, p := range positions {
+		c, err := cm.At(s.Bath[i].InteractionStrength)
+		if err != nil {
+			c = color.Black
+		}
+		sites = append(sites, projectedSite{x: p.Dot(right), y: p.Dot(up), depth: p.Dot(toViewer), color: c})
+		extent = math.Max(extent, p.Norm())
+	}
+	if extent == 0 {
+		return fmt.Errorf("the system holds no bath positions to render")
+	}
+	sort.SliceStable(sites, func(i, j int) bool { return sites[i].depth < sites[j].depth })
+
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = "(" + unit + ")"
+	p.Y.Label.Text = "(" + unit + ")"
+	p.X.Min, p.X.Max = -1.15*extent, 1.15*extent
+	p.Y.Min, p.Y.Max = -1.15*extent, 1.15*extent
+
+	axis := s.QuantisationAxis
+	if axis == (cs.Position{}) {
+		axis = cs.Position{Z: 1.0}
+	}
+	axis = axis.Scale(extent)
+	axisLine, err := plotter.NewLine(plotter.XYs{{X: -axis.Dot(right), Y: -axis.Dot(up)}, {X: axis.Dot(right), Y: axis.Dot(up)}})
+	if err != nil {
+		return err
+	}
+	axisLine.Color = color.Gray{Y: 160}
+	axisLine.Dashes = []vg.Length{vg.Points(4), vg.Points(3)}
+	p.Add(axisLine)
+
+	xys := make(plotter.XYs, len(sites))
+	for i, site := range sites {
+		xys[i] = plotter.XY{X: site.x, Y: site.y}
+	}
+	scatter, err := plotter.NewScatter(xys)
+	if err != nil {
+		return err
+	}
+	scatter.GlyphStyleFunc = func(i int) draw.GlyphStyle {
+		radius := vg.Points(4 + 2*sites[i].depth/extent)
+		if sites[i].central {
+			radius = vg.Points(7)
+		}
+		return draw.GlyphStyle{Color: sites[i].color, Radius: radius, Shape: draw.CircleGlyph{}}
+	}
+	p.Add(scatter)
+
+	bar := plot.New()
+	bar.HideX()
+	bar.Y.Label.Text = "coupling"
+	bar.Add(&plotter.ColorBar{ColorMap: cm, Vertical: true})
+
+	const width, height = 6 * vg.Inch, 5 * vg.Inch
+	c, err := draw.NewFormattedCanvas(width, height, format)
+	if err != nil {
+		return err
+	}
+	dc := draw.New(c)
+	p.Draw(draw.Crop(dc, 0, -1.3*vg.Inch, 0, 0))
+	bar.Draw(draw.Crop(dc, width-1.2*vg.Inch, 0, 0, 0))
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = c.WriteTo(f)
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	cs "github.com/korsakjakub/cs_q_sim/pkg/cs_q_sim"
+	"gonum.org/v1/plot/plotter"
 )
 
 /*
@@ -20,6 +21,10 @@ Calculate:
 *
 */
 func FindGeometryGivenInteractions(conf cs.Config) {
+	if conf.Physics.Design.Target != "" {
+		designGeometry(conf)
+		return
+	}
 	var bath []cs.State
 	conf.Physics.BathCount = len(conf.Physics.InteractionCoefficients) - 1
 	bc := conf.Physics.BathCount
@@ -30,9 +35,10 @@ func FindGeometryGivenInteractions(conf cs.Config) {
 	}
 
 	s := &cs.System{
-		CentralSpin:   cs.State{Angle: 0.0, Distance: 0.0},
-		Bath:          bath,
-		PhysicsConfig: conf.Physics,
+		CentralSpin:      cs.State{Angle: 0.0, Distance: 0.0},
+		Bath:             bath,
+		PhysicsConfig:    conf.Physics,
+		QuantisationAxis: cs.QuantisationAxis(conf.Physics),
 	}
 
 	if conf.Verbosity == "debug" {
@@ -63,3 +69,66 @@ func FindGeometryGivenInteractions(conf cs.Config) {
 	}
 	r.Write(conf.Files)
 }
+
+// designGeometry optimises the bath positions for the couplings of conf.Physics.Design, reporting the residual, the smallest spacing
+// and the target and found couplings against the site index. A design closer than MinSpacing is still written, flagged by "spacing violated"
+func designGeometry(conf cs.Config) {
+	start := time.Now()
+
+	if conf.Verbosity == "debug" {
+		fmt.Println("Optimise the bath positions...")
+	}
+	design, err := cs.DesignGeometry(conf.Physics)
+	spacingViolated := 0.0
+	if err != nil {
+		// only a violated spacing comes with the closest design
+		if len(design.Positions) == 0 {
+			panic(err)
+		}
+		fmt.Println(err)
+		spacingViolated = 1.0
+	}
+	conf.Physics.BathCount = len(design.Positions)
+
+	axis := cs.QuantisationAxis(conf.Physics)
+	bath := make([]cs.State, len(design.Positions))
+	var targets, found plotter.XYs
+	for i, p := range design.Positions {
+		bath[i] = cs.NewState(p, axis)
+		bath[i].InteractionStrength = design.Couplings[i]
+		targets = append(targets, plotter.XY{X: float64(i + 1), Y: design.Targets[i]})
+		found = append(found, plotter.XY{X: float64(i + 1), Y: design.Couplings[i]})
+	}
+	if conf.Verbosity == "debug" {
+		fmt.Printf("residual %v, smallest spacing %v\n", design.Residual, design.MinSpacing)
+	}
+
+	start_time := start.Format(time.RFC3339)
+
+	elapsed_time := time.Since(start)
+	r := cs.ResultsIO{
+		Filename: start_time,
+		Metadata: cs.Metadata{
+			Date:           start_time,
+			Simulation:     "Inverse geometry design",
+			SimulationId:   conf.Simulation,
+			Cpu:            conf.Files.ResultsConfig.Cpu,
+			Ram:            conf.Files.ResultsConfig.Ram,
+			CompletionTime: elapsed_time.String(),
+		},
+		Values: struct {
+			System cs.System "mapstructure:\"system\""
+		}{
+			System: cs.System{
+				CentralSpin:      cs.State{Angle: 0.0, Distance: 0.0},
+				Bath:             bath,
+				PhysicsConfig:    conf.Physics,
+				QuantisationAxis: axis,
+			},
+		},
+		XYs:     []plotter.XYs{targets, found},
+		Labels:  []string{"target", "found"},
+		Scalars: map[string]float64{"residual": design.Residual, "min spacing": design.MinSpacing, "spacing violated": spacingViolated},
+	}
+	r.Write(conf.Files)
+}
@@ -0,0 +1,105 @@
+package simulations
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	cs "github.com/korsakjakub/cs_q_sim/pkg/cs_q_sim"
+)
+
+// Largest bath for which the Holstein-Primakoff evolution is automatically compared with the exact one
+const hpCrossCheckMaxBathCount = 10
+
+func HolsteinPrimakoffEvolution(conf cs.Config) {
+	conf.Physics.BathCount = len(conf.Physics.InitialKet) - 1
+	maxBosons := conf.Physics.MaxBosons
+	start := time.Now()
+	startTime := start.Format(time.RFC3339)
+
+	var bath []cs.State
+	if len(conf.Physics.InteractionCoefficients) == 0 {
+		bath = cs.BathStates(conf.Physics)
+	} else {
+		bath = make([]cs.State, conf.Physics.BathCount)
+	}
+
+	s := &cs.System{
+		CentralSpin:      cs.State{Angle: 0.0, Distance: 0.0},
+		Bath:             bath,
+		PhysicsConfig:    conf.Physics,
+		QuantisationAxis: cs.QuantisationAxis(conf.Physics),
+	}
+
+	b := conf.Physics.BathMagneticField
+	b0 := conf.Physics.CentralMagneticField
+	initialKet := cs.HolsteinPrimakoffVector(conf.Physics.InitialKet, conf.Physics.Spin, maxBosons)
+
+	if conf.Verbosity == "debug" {
+		fmt.Printf("Collective coupling G = %v, Hilbert space dimension %v\n", s.CollectiveCoupling(), initialKet.Len())
+	}
+	eigen := s.Diagonalize(s.HolsteinPrimakoffHamiltonian(b0, b, maxBosons))
+
+	var series []stateSeries
+	for _, obs := range conf.Physics.ObservablesConfig {
+		if obs.Slot != 0 || obs.Expression != "" {
+			fmt.Printf("Skipping %v: only single operators of the central spin are resolved by the Holstein-Primakoff mapping\n", obs.Label())
+			continue
+		}
+		observable := cs.HolsteinPrimakoffObservable(spinOperator(obs.Operator, conf.Physics.Spin), maxBosons)
+		series = append(series, scalarSeries(fmt.Sprintf("HP <%v_0>", obs.Operator), observable.ExpectationValue))
+	}
+	xyss, labels := evolveSeries(conf.Physics, initialKet, eigen, series, conf.Verbosity == "debug")
+	scalars := map[string]float64{"collective coupling": s.CollectiveCoupling()}
+
+	if conf.Physics.BathCount <= hpCrossCheckMaxBathCount {
+		fmt.Println("Cross-checking against the exact evolution...")
+		s.DownSpins = cs.DownSpinCount(conf.Physics.InitialKet)
+		exactKet := s.InitialKet()
+		exactEigen := solveEigenProblem(s)
+		observables := prepareObservables(conf.Physics, s.DownSpins)
+
+		var exactSeries []stateSeries
+		for i, obs := range conf.Physics.ObservablesConfig {
+			if obs.Slot != 0 || obs.Expression != "" {
+				continue
+			}
+			exactSeries = append(exactSeries, scalarSeries(fmt.Sprintf("exact <%v_0>", obs.Operator), observables[i].ExpectationValue))
+		}
+		exactXyss, exactLabels := evolveSeries(conf.Physics, exactKet, exactEigen, exactSeries, conf.Verbosity == "debug")
+
+		maxDeviation := 0.0
+		for i := range exactXyss {
+			for t := range exactXyss[i] {
+				maxDeviation = math.Max(maxDeviation, math.Abs(exactXyss[i][t].Y-xyss[i][t].Y))
+			}
+		}
+		fmt.Printf("Largest deviation from the exact evolution: %v\n", maxDeviation)
+		scalars["max deviation from exact"] = maxDeviation
+		xyss = append(xyss, exactXyss...)
+		labels = append(labels, exactLabels...)
+	}
+
+	elapsedTime := time.Since(start)
+	r := cs.ResultsIO{
+		Filename: startTime,
+		Metadata: cs.Metadata{
+			Date:           startTime,
+			Simulation:     "Holstein-Primakoff central spin time evolution",
+			SimulationId:   conf.Simulation,
+			Cpu:            conf.Files.ResultsConfig.Cpu,
+			Ram:            conf.Files.ResultsConfig.Ram,
+			CompletionTime: elapsedTime.String(),
+			FiguresDir:     conf.Files.FigDir,
+		},
+		Values: struct {
+			System cs.System "mapstructure:\"system\""
+		}{
+			System: *s,
+		},
+		XYs:     xyss,
+		Labels:  labels,
+		Scalars: scalars,
+	}
+	r.Write(conf.Files)
+}
@@ -10,21 +10,19 @@ import (
 )
 
 func Interactions(conf cs.Config) {
-	var bath []cs.State
 	bc := conf.Physics.BathCount
 
 	start := time.Now()
-	for i := 0; i < bc; i += 1 {
-		bath = append(bath, cs.State{Angle: cs.PolarAngleCos(i, conf.Physics), Distance: conf.Physics.ConstantDistance})
-	}
+	bath := cs.BathStates(conf.Physics)
 
 	start_time := start.Format(time.RFC3339)
 	var xys plotter.XYs
 
 	s := &cs.System{
-		CentralSpin:   cs.State{Angle: 0.0, Distance: 0.0},
-		Bath:          bath,
-		PhysicsConfig: conf.Physics,
+		CentralSpin:      cs.State{Angle: 0.0, Distance: 0.0},
+		Bath:             bath,
+		PhysicsConfig:    conf.Physics,
+		QuantisationAxis: cs.QuantisationAxis(conf.Physics),
 	}
 
 	interactions := make([]float64, bc+1)
@@ -0,0 +1,134 @@
+package simulations
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	cs "github.com/korsakjakub/cs_q_sim/pkg/cs_q_sim"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/plot/plotter"
+)
+
+// LevelStatistics sweeps the tilt angle or the magnetic field and records, in every magnetisation sector and at every swept value,
+// the mean adjacent-gap ratio, the inverse participation ratios of the eigenvectors and the distribution of unfolded level spacings
+func LevelStatistics(conf cs.Config) {
+	start := time.Now()
+	lc := conf.Physics.LevelStatistics
+	sites := conf.Physics.BathCount + 1
+	if lc.UnfoldingDegree < 1 || lc.HistogramBins < 1 || lc.MaxSpacing <= 0 {
+		panic("levelstatistics needs a positive unfoldingdegree, histogrambins and maxspacing")
+	}
+
+	type point struct {
+		x, b0, b float64
+	}
+	var points []point
+	switch lc.Sweep {
+	case "tiltangle":
+		if len(conf.Physics.TiltAngleRange) != 2 {
+			panic("TiltAngleRange should have length 2. (min, max)")
+		}
+		requireTilt(conf.Physics, "a tilt angle sweep")
+		for tiltAngle := conf.Physics.TiltAngleRange[0]; tiltAngle < conf.Physics.TiltAngleRange[1]; tiltAngle += conf.Physics.Dt {
+			points = append(points, point{tiltAngle, conf.Physics.CentralMagneticField, conf.Physics.BathMagneticField})
+		}
+	case "magneticfield":
+		// both configured fields are scaled together by 0, 1, ..., MagneticFieldRange - 1, and the bath field is recorded
+		for i := 0.0; i < float64(conf.Physics.MagneticFieldRange); i += 1.0 {
+			b := i * conf.Physics.BathMagneticField
+			points = append(points, point{b, i * conf.Physics.CentralMagneticField, b})
+		}
+	default:
+		panic("unknown sweep: " + lc.Sweep)
+	}
+	if len(points) == 0 {
+		panic("levelstatistics sweeps no " + lc.Sweep + " values")
+	}
+
+	var labels []string
+	var xyss []plotter.XYs
+	sectors := make([][]plotter.XYs, sites+1) // <r>, mean IPR, IPR of every eigenvector
+	var histograms []plotter.XYs
+	var histogramLabels []string
+	for _, p := range points {
+		physics := conf.Physics
+		if lc.Sweep == "tiltangle" {
+			physics.TiltAngle = p.x
+		}
+		var bath []cs.State
+		if len(physics.InteractionCoefficients) == 0 {
+			bath = cs.BathStates(physics)
+		} else {
+			bath = make([]cs.State, physics.BathCount)
+		}
+		s := &cs.System{Bath: bath, PhysicsConfig: physics, QuantisationAxis: cs.QuantisationAxis(physics)}
+		full := mat.DenseCopyOf(s.Hamiltonian(p.b0, p.b))
+
+		for downCount := 0; downCount <= sites; downCount++ {
+			indices := cs.BasisIndices(sites, downCount)
+			if sectors[downCount] == nil {
+				sectors[downCount] = make([]plotter.XYs, 3)
+			}
+			if len(indices) < 3 {
+				continue
+			}
+			h := cs.RestrictMatrixToSubspace(full, indices)
+			eigen := s.Diagonalize(mat.NewSymDense(len(indices), h.RawMatrix().Data))
+
+			r := cs.AdjacentGapRatio(eigen.EigenValues, cs.DegeneracyTolerance(eigen.EigenValues))
+			meanIpr := 0.0
+			for n := range eigen.EigenValues {
+				ipr := cs.InverseParticipationRatio(eigen.EigenVectors.ColView(n))
+				meanIpr += ipr / float64(len(eigen.EigenValues))
+				sectors[downCount][2] = append(sectors[downCount][2], plotter.XY{X: p.x, Y: ipr})
+			}
+			sectors[downCount][0] = append(sectors[downCount][0], plotter.XY{X: p.x, Y: r})
+			sectors[downCount][1] = append(sectors[downCount][1], plotter.XY{X: p.x, Y: meanIpr})
+			spacings := cs.LevelSpacings(cs.UnfoldSpectrum(eigen.EigenValues, lc.UnfoldingDegree))
+			histograms = append(histograms, cs.SpacingHistogram(spacings, lc.HistogramBins, lc.MaxSpacing))
+			histogramLabels = append(histogramLabels, fmt.Sprintf("P(s) (%v down, %v = %v)", downCount, lc.Sweep, p.x))
+		}
+		if conf.Verbosity == "debug" {
+			fmt.Printf("%v = %v done\n", lc.Sweep, p.x)
+		}
+	}
+
+	for downCount, series := range sectors {
+		if len(series[0]) == 0 {
+			continue
+		}
+		xyss = append(xyss, series[0], series[1], series[2])
+		labels = append(labels,
+			fmt.Sprintf("<r> (%v down)", downCount),
+			fmt.Sprintf("mean IPR (%v down)", downCount),
+			fmt.Sprintf("IPR (%v down)", downCount),
+		)
+	}
+	xyss = append(xyss, histograms...)
+	labels = append(labels, histogramLabels...)
+
+	startTime := start.Format(time.RFC3339)
+	elapsedTime := time.Since(start)
+	r := cs.ResultsIO{
+		Filename: startTime,
+		Metadata: cs.Metadata{
+			Date:           startTime,
+			Simulation:     "Level statistics vs " + lc.Sweep,
+			SimulationId:   conf.Simulation,
+			Cpu:            conf.Files.ResultsConfig.Cpu,
+			Ram:            conf.Files.ResultsConfig.Ram,
+			CompletionTime: elapsedTime.String(),
+			FiguresDir:     conf.Files.FigDir,
+		},
+		Values: struct {
+			System cs.System "mapstructure:\"system\""
+		}{
+			System: cs.System{PhysicsConfig: conf.Physics},
+		},
+		XYs:     xyss,
+		Labels:  labels,
+		Scalars: map[string]float64{"poisson <r>": 2*math.Ln2 - 1, "goe <r> surmise": 4 - 2*math.Sqrt(3)},
+	}
+	r.Write(conf.Files)
+}
@@ -0,0 +1,111 @@
+package simulations
+
+import (
+	"fmt"
+	"time"
+
+	cs "github.com/korsakjakub/cs_q_sim/pkg/cs_q_sim"
+	"gonum.org/v1/plot/plotter"
+)
+
+// OptimiseTiltAngle finds all the tilt angles within TiltAngleRange that optimise the spread of the couplings or the decay time,
+// or at which they reach a target value, as set by TiltOptimisation. The optima are written as the series "optima" and as numbered scalars
+func OptimiseTiltAngle(conf cs.Config) {
+	start := time.Now()
+
+	if len(conf.Physics.TiltAngleRange) != 2 {
+		panic("TiltAngleRange should have length 2. (min, max)")
+	}
+	requireTilt(conf.Physics, "the tilt angle optimisation")
+	oc := conf.Physics.TiltOptimisation
+	if oc.Samples == 0 {
+		oc.Samples = 100
+	}
+	if oc.Tolerance == 0 {
+		oc.Tolerance = 1e-10
+	}
+
+	// the quantity is sampled on copies of the config, so the results keep the configured tilt angle
+	var quantity func(float64) float64
+	switch oc.Quantity {
+	case "spread":
+		quantity = func(tiltAngle float64) float64 {
+			sampled := conf
+			sampled.Physics.TiltAngle = tiltAngle
+			return spread(prepareStates(sampled)) * 1e-3
+		}
+		if oc.Goal == "" {
+			oc.Goal = "min"
+		}
+	case "decay-time":
+		quantity = func(tiltAngle float64) float64 {
+			sampled := conf
+			sampled.Physics.TiltAngle = tiltAngle
+			return decayTime(spread(prepareStates(sampled)))
+		}
+		if oc.Goal == "" {
+			oc.Goal = "max"
+		}
+	default:
+		panic(fmt.Sprintf("unknown quantity %q to optimise, expected spread or decay-time", oc.Quantity))
+	}
+
+	a, b := conf.Physics.TiltAngleRange[0], conf.Physics.TiltAngleRange[1]
+	var optima []cs.Extremum
+	switch oc.Goal {
+	case "min":
+		optima = cs.LocalMinima(quantity, a, b, oc.Samples, oc.Tolerance)
+	case "max":
+		optima = cs.LocalMinima(func(x float64) float64 { return -quantity(x) }, a, b, oc.Samples, oc.Tolerance)
+		for i := range optima {
+			optima[i].F = -optima[i].F
+		}
+	case "target":
+		optima = cs.Roots(func(x float64) float64 { return quantity(x) - oc.Target }, a, b, oc.Samples, oc.Tolerance)
+		for i := range optima {
+			optima[i].F += oc.Target
+		}
+	default:
+		panic(fmt.Sprintf("unknown goal %q, expected min, max or target", oc.Goal))
+	}
+	conf.Physics.TiltOptimisation = oc
+
+	var xys plotter.XYs
+	scalars := map[string]float64{"optima": float64(len(optima))}
+	for i, o := range optima {
+		xys = append(xys, plotter.XY{X: o.X, Y: o.F})
+		scalars[fmt.Sprintf("tilt angle %v", i+1)] = o.X
+		scalars[fmt.Sprintf("%v %v", oc.Quantity, i+1)] = o.F
+		if o.Boundary {
+			scalars[fmt.Sprintf("boundary %v", i+1)] = 1
+		}
+		if conf.Verbosity == "debug" {
+			fmt.Printf("tilt angle %v: %v = %v\n", o.X, oc.Quantity, o.F)
+		}
+	}
+
+	start_time := start.Format(time.RFC3339)
+
+	elapsed_time := time.Since(start)
+	r := cs.ResultsIO{
+		Filename: start_time,
+		Metadata: cs.Metadata{
+			Date:           start_time,
+			Simulation:     "Optimal tilt angles",
+			SimulationId:   conf.Simulation,
+			Cpu:            conf.Files.ResultsConfig.Cpu,
+			Ram:            conf.Files.ResultsConfig.Ram,
+			CompletionTime: elapsed_time.String(),
+			FiguresDir:     conf.Files.FigDir,
+		},
+		Values: struct {
+			System cs.System "mapstructure:\"system\""
+		}{
+			System: cs.System{PhysicsConfig: conf.Physics},
+		},
+		XYs:     []plotter.XYs{xys},
+		Labels:  []string{"optima"},
+		Scalars: scalars,
+	}
+	r.Write(conf.Files)
+}
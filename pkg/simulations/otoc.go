@@ -0,0 +1,87 @@
+package simulations
+
+import (
+	"fmt"
+	"time"
+
+	cs "github.com/korsakjakub/cs_q_sim/pkg/cs_q_sim"
+	"gonum.org/v1/gonum/mat"
+)
+
+// OutOfTimeOrderCorrelators computes F(t) = <W†(t) V_j† W(t) V_j> for V placed at every selected slot j,
+// resolving site by site how the operator W spreads from its slot into the bath
+func OutOfTimeOrderCorrelators(conf cs.Config) {
+	conf.Physics.BathCount = len(conf.Physics.InitialKet) - 1
+	oc := conf.Physics.Otoc
+	sites := conf.Physics.BathCount + 1
+	if len(oc.VSlots) == 0 {
+		for j := 1; j < sites; j++ {
+			oc.VSlots = append(oc.VSlots, j)
+		}
+	}
+	start := time.Now()
+	startTime := start.Format(time.RFC3339)
+
+	var bath []cs.State
+	if len(conf.Physics.InteractionCoefficients) == 0 {
+		bath = cs.BathStates(conf.Physics)
+	} else {
+		bath = make([]cs.State, conf.Physics.BathCount)
+	}
+
+	// W and V need not conserve the magnetisation, so the full Hilbert space is used
+	s := &cs.System{
+		CentralSpin:      cs.State{Angle: 0.0, Distance: 0.0},
+		Bath:             bath,
+		PhysicsConfig:    conf.Physics,
+		QuantisationAxis: cs.QuantisationAxis(conf.Physics),
+	}
+	initialKet := s.InitialKet()
+	fmt.Println("Diagonalizing...")
+	eigen := solveEigenProblem(s)
+
+	w := cs.ManyBodyOperator(spinOperator(oc.W, conf.Physics.Spin), oc.WSlot, sites)
+	vs := make([]mat.Matrix, len(oc.VSlots))
+	var labels []string
+	for i, slot := range oc.VSlots {
+		vs[i] = cs.ManyBodyOperator(spinOperator(oc.V, conf.Physics.Spin), slot, sites)
+		f := fmt.Sprintf("F(%v_%v, %v_%v)", oc.W, oc.WSlot, oc.V, slot)
+		labels = append(labels, "Re "+f, "Im "+f)
+	}
+	ket := make([]complex128, initialKet.Len())
+	for i, v := range initialKet.RawVector().Data {
+		ket[i] = complex(v, 0)
+	}
+
+	fmt.Println("Calculating OTOCs...")
+	// the OTOCs act on the initial ket directly, so the evolved state of evolveSeries is not needed
+	xyss := timeSeries(conf.Physics, len(labels), conf.Verbosity == "debug", func(time float64) []float64 {
+		var values []float64
+		for _, f := range cs.OTOCs(w, vs, eigen, time, ket) {
+			values = append(values, real(f), imag(f))
+		}
+		return values
+	})
+
+	elapsedTime := time.Since(start)
+	r := cs.ResultsIO{
+		Filename: startTime,
+		Metadata: cs.Metadata{
+			Date:           startTime,
+			Simulation:     "Out-of-time-order correlators",
+			SimulationId:   conf.Simulation,
+			Cpu:            conf.Files.ResultsConfig.Cpu,
+			Ram:            conf.Files.ResultsConfig.Ram,
+			CompletionTime: elapsedTime.String(),
+			FiguresDir:     conf.Files.FigDir,
+		},
+		Values: struct {
+			System cs.System "mapstructure:\"system\""
+		}{
+			System: *s,
+		},
+		XYs:    xyss,
+		Labels: labels,
+	}
+	r.Write(conf.Files)
+}
@@ -0,0 +1,117 @@
+package simulations
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	cs "github.com/korsakjakub/cs_q_sim/pkg/cs_q_sim"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/plot/plotter"
+)
+
+// QuantumFisher computes the quantum Fisher information F_Q(t) of the evolved state with respect to the configured parameter,
+// together with the quantum Cramér-Rao bounds Δλ ≥ 1/sqrt(F_Q) for a single shot and Δλ sqrt(T) ≥ sqrt(t/F_Q) for repeated shots within a total time T
+func QuantumFisher(conf cs.Config) {
+	conf.Physics.BathCount = len(conf.Physics.InitialKet) - 1
+	fc := conf.Physics.Fisher
+	if fc.Step == 0 {
+		panic("fisher needs a non-zero step for the central difference of the Hamiltonian")
+	}
+	if fc.Parameter == "tiltangle" {
+		requireTilt(conf.Physics, "the tilt angle Fisher parameter")
+	}
+	start := time.Now()
+	startTime := start.Format(time.RFC3339)
+
+	var bath []cs.State
+	if len(conf.Physics.InteractionCoefficients) == 0 {
+		bath = cs.BathStates(conf.Physics)
+	} else {
+		bath = make([]cs.State, conf.Physics.BathCount)
+	}
+	s := &cs.System{
+		CentralSpin:      cs.State{Angle: 0.0, Distance: 0.0},
+		Bath:             bath,
+		PhysicsConfig:    conf.Physics,
+		QuantisationAxis: cs.QuantisationAxis(conf.Physics),
+		DownSpins:        cs.DownSpinCount(conf.Physics.InitialKet),
+	}
+	initialKet := s.InitialKet()
+	fmt.Println("Diagonalizing...")
+	eigen := solveEigenProblem(s)
+	gramMatrix := cs.Grammian(initialKet, eigen.EigenVectors)
+
+	var dH mat.Dense
+	dH.Sub(systemHamiltonian(shiftedSystem(s, fc, fc.Step)), systemHamiltonian(shiftedSystem(s, fc, -fc.Step)))
+	dH.Scale(0.5/fc.Step, &dH)
+
+	tolerance := cs.DegeneracyTolerance(eigen.EigenValues)
+	fmt.Println("Calculating the quantum Fisher information...")
+	// the QFI follows from the overlaps of the initial ket, so no state is evolved
+	fisher := timeSeries(conf.Physics, 1, conf.Verbosity == "debug", func(time float64) []float64 {
+		return []float64{cs.QuantumFisherInformation(&dH, eigen, gramMatrix, time, tolerance)}
+	})[0]
+	// the bounds are infinite where F_Q vanishes (at t = 0 in particular), so they are only written where it is positive
+	var singleShot, rate plotter.XYs
+	bestSingleShot, bestRate := math.Inf(1), math.Inf(1)
+	for _, p := range fisher {
+		if p.Y <= 0 {
+			continue
+		}
+		singleShot = append(singleShot, plotter.XY{X: p.X, Y: 1 / math.Sqrt(p.Y)})
+		rate = append(rate, plotter.XY{X: p.X, Y: math.Sqrt(2 * math.Pi * p.X / p.Y)}) // x is t / 2π
+		bestSingleShot = math.Min(bestSingleShot, singleShot[len(singleShot)-1].Y)
+		bestRate = math.Min(bestRate, rate[len(rate)-1].Y)
+	}
+	xyss := []plotter.XYs{fisher, singleShot, rate}
+	labels := []string{"F_Q", "single-shot bound", "sqrt(T) bound"}
+	scalars := map[string]float64{}
+	if len(singleShot) > 0 {
+		scalars["best single-shot bound"] = bestSingleShot
+		scalars["best sqrt(T) bound"] = bestRate
+	}
+
+	elapsedTime := time.Since(start)
+	r := cs.ResultsIO{
+		Filename: startTime,
+		Metadata: cs.Metadata{
+			Date:           startTime,
+			Simulation:     "Quantum Fisher information w.r.t. " + fc.Parameter,
+			SimulationId:   conf.Simulation,
+			Cpu:            conf.Files.ResultsConfig.Cpu,
+			Ram:            conf.Files.ResultsConfig.Ram,
+			CompletionTime: elapsedTime.String(),
+			FiguresDir:     conf.Files.FigDir,
+		},
+		Values: struct {
+			System cs.System "mapstructure:\"system\""
+		}{
+			System: *s,
+		},
+		XYs:     xyss,
+		Labels:  labels,
+		Scalars: scalars,
+	}
+	r.Write(conf.Files)
+}
+
+// shiftedSystem returns a copy of the system with the Fisher parameter shifted by delta
+func shiftedSystem(s *cs.System, fc cs.FisherConfig, delta float64) *cs.System {
+	switch fc.Parameter {
+	case "centralmagneticfield":
+		shifted := *s
+		shifted.PhysicsConfig.CentralMagneticField += delta
+		return &shifted
+	case "bathmagneticfield":
+		shifted := *s
+		shifted.PhysicsConfig.BathMagneticField += delta
+		return &shifted
+	case "tiltangle":
+		return perturbedSystem(s, cs.LoschmidtConfig{TiltAngleOffset: delta})
+	case "coupling":
+		return perturbedSystem(s, cs.LoschmidtConfig{JitterSlot: fc.Slot, Jitter: delta})
+	default:
+		panic("unknown Fisher parameter: " + fc.Parameter)
+	}
+}
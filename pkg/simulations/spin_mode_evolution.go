@@ -0,0 +1,85 @@
+package simulations
+
+import (
+	"fmt"
+	"time"
+
+	cs "github.com/korsakjakub/cs_q_sim/pkg/cs_q_sim"
+	"gonum.org/v1/gonum/mat"
+)
+
+// SpinModeEvolution evolves the spins together with a truncated bosonic mode (a cavity or a motional mode) coupled to the central spin.
+// The initial ket carries the Fock state of the mode, e.g. "duuu|0>", and the mode is addressed by observables at slot BathCount+1
+func SpinModeEvolution(conf cs.Config) {
+	spins, fock, _ := cs.SplitFockState(conf.Physics.InitialKet)
+	conf.Physics.BathCount = len(spins) - 1
+	mode := conf.Physics.Mode
+	start := time.Now()
+	startTime := start.Format(time.RFC3339)
+
+	var bath []cs.State
+	if len(conf.Physics.InteractionCoefficients) == 0 {
+		bath = cs.BathStates(conf.Physics)
+	} else {
+		bath = make([]cs.State, conf.Physics.BathCount)
+	}
+
+	s := &cs.System{
+		CentralSpin:      cs.State{Angle: 0.0, Distance: 0.0},
+		Bath:             bath,
+		PhysicsConfig:    conf.Physics,
+		QuantisationAxis: cs.QuantisationAxis(conf.Physics),
+	}
+
+	initialKet := cs.ModeVector(spins, conf.Physics.Spin, fock, mode.MaxBosons)
+	if conf.Verbosity == "debug" {
+		fmt.Printf("Hilbert space dimension: %v\n", initialKet.Len())
+	}
+	fmt.Println("Diagonalizing...")
+	eigen := s.Diagonalize(s.HamiltonianWithMode(conf.Physics.CentralMagneticField, conf.Physics.BathMagneticField, mode))
+
+	dims := s.ModeDims(mode.MaxBosons)
+	modeSlot := len(dims) - 1
+	var series []stateSeries
+	for _, obs := range conf.Physics.ObservablesConfig {
+		if obs.Expression != "" {
+			fmt.Printf("Skipping %v: expressions are not supported together with the bosonic mode\n", obs.Label())
+			continue
+		}
+		var operator *mat.Dense
+		if obs.Slot == modeSlot {
+			operator = modeOperator(obs.Operator, mode.MaxBosons)
+		} else if obs.Slot < modeSlot {
+			operator = spinOperator(obs.Operator, conf.Physics.Spin)
+		} else {
+			continue
+		}
+		observable := cs.Observable{Dense: *cs.ManyBodyOperatorWithDims(operator, obs.Slot, dims)}
+		series = append(series, scalarSeries(fmt.Sprintf("<%v_%v>", obs.Operator, obs.Slot), observable.ExpectationValue))
+	}
+
+	fmt.Println("Calculating time evolution...")
+	xyss, labels := evolveSeries(conf.Physics, initialKet, eigen, series, conf.Verbosity == "debug")
+
+	elapsedTime := time.Since(start)
+	r := cs.ResultsIO{
+		Filename: startTime,
+		Metadata: cs.Metadata{
+			Date:           startTime,
+			Simulation:     "Central spin and bosonic mode time evolution",
+			SimulationId:   conf.Simulation,
+			Cpu:            conf.Files.ResultsConfig.Cpu,
+			Ram:            conf.Files.ResultsConfig.Ram,
+			CompletionTime: elapsedTime.String(),
+			FiguresDir:     conf.Files.FigDir,
+		},
+		Values: struct {
+			System cs.System "mapstructure:\"system\""
+		}{
+			System: *s,
+		},
+		XYs:    xyss,
+		Labels: labels,
+	}
+	r.Write(conf.Files)
+}
@@ -3,41 +3,72 @@ package simulations
 import (
 	"fmt"
 	"math"
-	"sort"
-	"sync"
 	"time"
 
-	"github.com/gosuri/uiprogress"
 	cs "github.com/korsakjakub/cs_q_sim/pkg/cs_q_sim"
 	"gonum.org/v1/plot/plotter"
 )
 
 func SpinTimeEvolution(conf cs.Config) {
-	conf.Physics.BathCount = len(conf.Physics.InitialKet) - 1
-	downSpins := downSpins(conf.Physics.InitialKet)
-	timeRange := conf.Physics.TimeRange
-	observables := prepareObservables(conf.Physics, downSpins)
 	start := time.Now()
 	startTime := start.Format(time.RFC3339)
+	s, xyss, labels, scalars := spinTimeEvolution(conf, conf.Files.OutputsDir+"diag-"+startTime)
+
+	if conf.Verbosity == "debug" {
+		fmt.Println("Wrapping up...")
+	}
+	elapsedTime := time.Since(start)
+	r := cs.ResultsIO{
+		Filename: startTime,
+		Metadata: cs.Metadata{
+			Date:           startTime,
+			Simulation:     "Central spin expectation value time evolution",
+			SimulationId:   conf.Simulation,
+			Cpu:            conf.Files.ResultsConfig.Cpu,
+			Ram:            conf.Files.ResultsConfig.Ram,
+			CompletionTime: elapsedTime.String(),
+			FiguresDir:     conf.Files.FigDir,
+		},
+		Values: struct {
+			System cs.System "mapstructure:\"system\""
+		}{
+			System: *s,
+		},
+		XYs:     xyss,
+		Labels:  labels,
+		Scalars: scalars,
+	}
+	r.Write(conf.Files)
+}
+
+// spinTimeEvolution computes the observables, correlations and other series of SpinTimeEvolution.
+// The diagonalization is saved to diagPath unless it is empty
+func spinTimeEvolution(conf cs.Config, diagPath string) (*cs.System, []plotter.XYs, []string, map[string]float64) {
+	conf.Physics.BathCount = len(conf.Physics.InitialKet) - 1
+	downSpins := cs.DownSpinCount(conf.Physics.InitialKet)
+	observables := prepareObservables(conf.Physics, downSpins)
+	// the series are built, and so validated, before the diagonalization
+	correlations := prepareCorrelations(conf.Physics, downSpins)
+	entanglement := prepareEntanglement(conf.Physics, downSpins)
+	centralSpinState := prepareCentralSpinState(conf.Physics, downSpins)
 
 	var bath []cs.State
 	if len(conf.Physics.InteractionCoefficients) == 0 {
 		fmt.Println("Calculating initial states...")
-		for i := 0; i < conf.Physics.BathCount; i += 1 {
-			bath = append(bath, cs.State{Angle: cs.PolarAngleCos(i, conf.Physics), Distance: conf.Physics.ConstantDistance})
-		}
+		bath = cs.BathStates(conf.Physics)
 	} else {
 		fmt.Println("Using initial states from config...")
 		bath = make([]cs.State, conf.Physics.BathCount)
 	}
 
 	s := &cs.System{
-		CentralSpin:   cs.State{Angle: 0.0, Distance: 0.0},
-		Bath:          bath,
-		PhysicsConfig: conf.Physics,
-		DownSpins:     downSpins,
+		CentralSpin:      cs.State{Angle: 0.0, Distance: 0.0},
+		Bath:             bath,
+		PhysicsConfig:    conf.Physics,
+		QuantisationAxis: cs.QuantisationAxis(conf.Physics),
+		DownSpins:        downSpins,
 	}
-	initialKet := prepareInitialKet(s)
+	initialKet := s.InitialKet()
 
 	if conf.Verbosity == "debug" && s.DownSpins > 0 {
 		fmt.Printf("Reduced the dimension: %v -> %v\n\n", math.Pow(2.0, float64(conf.Physics.BathCount+1)), len(initialKet.RawVector().Data))
@@ -50,7 +81,9 @@ func SpinTimeEvolution(conf cs.Config) {
 	} else {
 		fmt.Println("Diagonalizing...")
 		eigen = solveEigenProblem(s)
-		cs.SaveDiagonalizationSolutions(eigen, *s, conf.Files.OutputsDir+"diag-"+startTime)
+		if diagPath != "" {
+			cs.SaveDiagonalizationSolutions(eigen, *s, diagPath)
+		}
 	}
 
 	if conf.Verbosity == "debug" {
@@ -59,74 +92,29 @@ func SpinTimeEvolution(conf cs.Config) {
 
 	gramMatrix := cs.Grammian(initialKet, eigen.EigenVectors)
 
-	if conf.Verbosity == "debug" {
-		fmt.Println("Calculating time evolution...")
+	labels := make([]string, len(observables))
+	series := make([]stateSeries, len(observables))
+	for i, obs := range conf.Physics.ObservablesConfig {
+		labels[i] = obs.Label()
+		series[i] = scalarSeries(labels[i], observables[i].ExpectationValue)
 	}
-
-	type expVal struct {
-		exp   float64
-		index int
+	scalars := make(map[string]float64)
+	tolerance := cs.DegeneracyTolerance(eigen.EigenValues)
+	for i := range observables {
+		average, variance := cs.DiagonalEnsemble(&observables[i].Dense, eigen, gramMatrix, tolerance)
+		scalars[labels[i]+" long-time average"] = average
+		scalars[labels[i]+" fluctuation variance"] = variance
 	}
 
-	xyss := make([]plotter.XYs, len(observables))
-	for i, observable := range observables {
-		var xys plotter.XYs
-		expValChannel := make(chan expVal, timeRange)
-		var wg sync.WaitGroup
-		uiprogress.Start()
-		progressBar := uiprogress.AddBar(timeRange).AppendCompleted().PrependElapsed()
-
-		for t := 0; t < timeRange; t++ {
-			evolutionTime := conf.Physics.Dt * float64(t)
-			wg.Add(1)
-			go func(ch chan expVal, time int) {
-				ch <- expVal{exp: observable.ExpectationValue(cs.Evolve(initialKet, evolutionTime, eigen.EigenValues, eigen.EigenVectors, gramMatrix)), index: time}
-				if conf.Verbosity == "debug" {
-					progressBar.Incr()
-				}
-				wg.Done()
-			}(expValChannel, t)
-		}
-
-		wg.Wait()
-		expValues := make([]expVal, 0, timeRange)
-		close(expValChannel)
-
-		for e := range expValChannel {
-			expValues = append(expValues, e)
-		}
-
-		sort.Slice(expValues, func(i, j int) bool {
-			return expValues[i].index < expValues[j].index
-		})
-
-		for t := 0; t < timeRange; t++ {
-			xys = append(xys, plotter.XY{X: float64(expValues[t].index) * conf.Physics.Dt / (2.0 * math.Pi), Y: expValues[t].exp})
-		}
-		xyss[i] = xys
-	}
+	series = append(series, correlations...)
+	series = append(series, entanglement...)
+	series = append(series, prepareLoschmidt(s, initialKet, eigen)...)
+	series = append(series, centralSpinState...)
 
 	if conf.Verbosity == "debug" {
-		fmt.Println("Wrapping up...")
-	}
-	elapsedTime := time.Since(start)
-	r := cs.ResultsIO{
-		Filename: startTime,
-		Metadata: cs.Metadata{
-			Date:           startTime,
-			Simulation:     "Central spin expectation value time evolution",
-			SimulationId:   conf.Simulation,
-			Cpu:            conf.Files.ResultsConfig.Cpu,
-			Ram:            conf.Files.ResultsConfig.Ram,
-			CompletionTime: elapsedTime.String(),
-			FiguresDir:     conf.Files.FigDir,
-		},
-		Values: struct {
-			System cs.System "mapstructure:\"system\""
-		}{
-			System: *s,
-		},
-		XYs: xyss,
+		fmt.Println("Calculating time evolution...")
 	}
-	r.Write(conf.Files)
+	xyss, labels := evolveSeries(conf.Physics, initialKet, eigen, series, conf.Verbosity == "debug")
+
+	return s, xyss, labels, scalars
 }
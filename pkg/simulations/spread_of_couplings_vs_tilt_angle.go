@@ -9,27 +9,7 @@ import (
 
 func SpreadOfCouplingsVsTiltAngle(conf cs.Config) {
 	start := time.Now()
-
-	if len(conf.Physics.TiltAngleRange) != 2 {
-		panic("TiltAngleRange should have length 2. (min, max)")
-	}
-	minTiltAngle := conf.Physics.TiltAngleRange[0]
-	maxTiltAngle := conf.Physics.TiltAngleRange[1]
-
-	tiltAngle := minTiltAngle
-	var xys plotter.XYs
-
-	for tiltAngle < maxTiltAngle {
-		conf.Physics.TiltAngle = tiltAngle
-		states := prepareStates(conf)
-		spread := spread(states)
-		if spread < 1e-8 {
-			spread = 0.0
-		}
-		xys = append(xys, plotter.XY{X: tiltAngle, Y: spread * 1e-3})
-
-		tiltAngle += conf.Physics.Dt
-	}
+	xys := spreadCurve(conf)
 
 	start_time := start.Format(time.RFC3339)
 
@@ -53,3 +33,29 @@ func SpreadOfCouplingsVsTiltAngle(conf cs.Config) {
 	}
 	r.Write(conf.Files)
 }
+
+// spreadCurve returns the spread of the couplings (in units of 10^3) over TiltAngleRange, sampled every Dt
+func spreadCurve(conf cs.Config) plotter.XYs {
+	if len(conf.Physics.TiltAngleRange) != 2 {
+		panic("TiltAngleRange should have length 2. (min, max)")
+	}
+	requireTilt(conf.Physics, "a tilt angle sweep")
+	minTiltAngle := conf.Physics.TiltAngleRange[0]
+	maxTiltAngle := conf.Physics.TiltAngleRange[1]
+
+	tiltAngle := minTiltAngle
+	var xys plotter.XYs
+
+	for tiltAngle < maxTiltAngle {
+		conf.Physics.TiltAngle = tiltAngle
+		states := prepareStates(conf)
+		spread := spread(states)
+		if spread < 1e-8 {
+			spread = 0.0
+		}
+		xys = append(xys, plotter.XY{X: tiltAngle, Y: spread * 1e-3})
+
+		tiltAngle += conf.Physics.Dt
+	}
+	return xys
+}
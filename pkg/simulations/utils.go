@@ -1,10 +1,15 @@
 package simulations
 
 import (
+	"fmt"
 	"math"
+	"runtime"
+	"sync"
 
+	"github.com/gosuri/uiprogress"
 	cs "github.com/korsakjakub/cs_q_sim/pkg/cs_q_sim"
 	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/plot/plotter"
 )
 
 type spectrumInput struct {
@@ -33,17 +38,19 @@ func spread(states []cs.State) float64 {
 }
 
 func prepareStates(conf cs.Config) []cs.State {
-	var bath []cs.State
 	bc := conf.Physics.BathCount
-
-	for i := 0; i < bc; i += 1 {
-		bath = append(bath, cs.State{Angle: cs.PolarAngleCos(i, conf.Physics), Distance: conf.Physics.ConstantDistance})
+	var bath []cs.State
+	if len(conf.Physics.InteractionCoefficients) == 0 {
+		bath = cs.BathStates(conf.Physics)
+	} else {
+		bath = make([]cs.State, bc)
 	}
 
 	s := &cs.System{
-		CentralSpin:   cs.State{Angle: 0.0, Distance: 0.0},
-		Bath:          bath,
-		PhysicsConfig: conf.Physics,
+		CentralSpin:      cs.State{Angle: 0.0, Distance: 0.0},
+		Bath:             bath,
+		PhysicsConfig:    conf.Physics,
+		QuantisationAxis: cs.QuantisationAxis(conf.Physics),
 	}
 
 	for j := 0; j <= bc; j += 1 {
@@ -56,22 +63,20 @@ func prepareObservables(conf cs.PhysicsConfig, downSpins int) []cs.Observable {
 	observables := make([]cs.Observable, len(conf.ObservablesConfig))
 	ketLength := len(conf.InitialKet)
 	for i, obs := range conf.ObservablesConfig {
-		if obs.Slot > ketLength {
+		var fullObservable *mat.Dense
+		switch {
+		case obs.Expression != "":
+			var err error
+			if fullObservable, err = cs.ParseOperator(obs.Expression, conf.Spin, ketLength); err != nil {
+				panic(err)
+			}
+		case obs.Slot > ketLength:
 			continue
-		}
-		var operator *mat.Dense
-		switch obs.Operator {
-		case "Sz":
-			operator = cs.Sz(conf.Spin)
-		case "Sp":
-			operator = cs.Sp(conf.Spin)
-		case "Sm":
-			operator = cs.Sm(conf.Spin)
 		default:
-			operator = cs.Id(conf.Spin)
+			fullObservable = cs.ManyBodyOperator(spinOperator(obs.Operator, conf.Spin), obs.Slot, ketLength)
 		}
-		fullObservable := cs.ManyBodyOperator(operator, obs.Slot, ketLength)
-		if downSpins < 2 {
+		// the ket and the Hamiltonian are restricted from a single down spin on, so the observables have to follow
+		if downSpins < 1 {
 			observables[i] = cs.Observable{Dense: *fullObservable}
 		} else {
 			indices := cs.BasisIndices(conf.BathCount+1, downSpins)
@@ -81,38 +86,373 @@ func prepareObservables(conf cs.PhysicsConfig, downSpins int) []cs.Observable {
 	return observables
 }
 
+// prepareCorrelations returns the correlators requested in the config, evaluated on states of the (possibly restricted) basis.
+// Each entry yields the real and imaginary parts of <A_i B_j> for all its pairs (i, j), since A_i B_j need not be hermitian
+func prepareCorrelations(conf cs.PhysicsConfig, downSpins int) []stateSeries {
+	sites := conf.BathCount + 1
+	fullDim := int(math.Pow(2*conf.Spin+1, float64(sites)))
+	var indices []int
+	if downSpins > 0 {
+		indices = cs.BasisIndices(sites, downSpins)
+	}
+
+	series := make([]stateSeries, 0, len(conf.Correlations))
+	for _, corr := range conf.Correlations {
+		if len(corr.Operators) != 2 {
+			panic("a correlation needs exactly two operators")
+		}
+		a := spinOperator(corr.Operators[0], conf.Spin)
+		b := spinOperator(corr.Operators[1], conf.Spin)
+
+		var slots []int
+		var pairs [][2]int // positions in slots
+		switch corr.Pairs {
+		case "central-bath":
+			for j := 0; j < sites; j++ {
+				slots = append(slots, j)
+			}
+			for l := 1; l < sites; l++ {
+				pairs = append(pairs, [2]int{0, l})
+			}
+		case "bath-bath":
+			for j := 1; j < sites; j++ {
+				slots = append(slots, j)
+			}
+			for k := range slots {
+				for l := range slots {
+					pairs = append(pairs, [2]int{k, l})
+				}
+			}
+		default:
+			if len(corr.Slots) != 2 {
+				panic("a correlation needs two slots or a pairs specification")
+			}
+			slots = corr.Slots
+			pairs = [][2]int{{0, 1}}
+		}
+
+		var labels []string
+		suffix := ""
+		if corr.Connected {
+			suffix = "_c"
+		}
+		for _, p := range pairs {
+			label := fmt.Sprintf("<%v_%v %v_%v>%v", corr.Operators[0], slots[p[0]], corr.Operators[1], slots[p[1]], suffix)
+			labels = append(labels, "Re "+label, "Im "+label)
+		}
+
+		connected := corr.Connected
+		series = append(series, stateSeries{labels: labels, eval: func(_ float64, state []complex128) []float64 {
+			if indices != nil {
+				state = cs.EmbedInFullSpace(state, indices, fullDim)
+			}
+			c := cs.CorrelationMatrix(a, b, slots, sites, state, connected)
+			values := make([]float64, 0, 2*len(pairs))
+			for _, p := range pairs {
+				values = append(values, real(c.At(p[0], p[1])), imag(c.At(p[0], p[1])))
+			}
+			return values
+		}})
+	}
+	return series
+}
+
+// prepareCentralSpinState records the elements of the reduced density matrix of the central spin (upper triangle, the rest follows from hermiticity),
+// its Bloch vector, purity and l1-coherence
+func prepareCentralSpinState(conf cs.PhysicsConfig, downSpins int) []stateSeries {
+	if !conf.CentralSpinState {
+		return nil
+	}
+	sites := conf.BathCount + 1
+	localDim := int(2*conf.Spin + 1)
+	fullDim := int(math.Pow(float64(localDim), float64(sites)))
+	var indices []int
+	if downSpins > 0 {
+		indices = cs.BasisIndices(sites, downSpins)
+	}
+
+	var labels []string
+	for i := 0; i < localDim; i++ {
+		labels = append(labels, fmt.Sprintf("Re rho_%v%v", i, i))
+		for j := i + 1; j < localDim; j++ {
+			labels = append(labels, fmt.Sprintf("Re rho_%v%v", i, j), fmt.Sprintf("Im rho_%v%v", i, j))
+		}
+	}
+	labels = append(labels, "bloch_x", "bloch_y", "bloch_z", "purity(central)", "l1-coherence")
+
+	return []stateSeries{{labels: labels, eval: func(_ float64, state []complex128) []float64 {
+		if indices != nil {
+			state = cs.EmbedInFullSpace(state, indices, fullDim)
+		}
+		rho := cs.ReducedDensityMatrix(state, []int{0}, sites, localDim)
+		var values []float64
+		for i := 0; i < localDim; i++ {
+			values = append(values, rho.Re.At(i, i))
+			for j := i + 1; j < localDim; j++ {
+				values = append(values, rho.Re.At(i, j), rho.Im.At(i, j))
+			}
+		}
+		bloch := rho.BlochVector(conf.Spin)
+		return append(values, bloch[0], bloch[1], bloch[2], rho.Purity(), rho.L1Coherence())
+	}}}
+}
+
+// prepareEntanglement returns the entanglement measures of the bipartitions requested in the config
+func prepareEntanglement(conf cs.PhysicsConfig, downSpins int) []stateSeries {
+	sites := conf.BathCount + 1
+	localDim := int(2*conf.Spin + 1)
+	fullDim := int(math.Pow(float64(localDim), float64(sites)))
+	var indices []int
+	if downSpins > 0 {
+		indices = cs.BasisIndices(sites, downSpins)
+	}
+
+	series := make([]stateSeries, 0, len(conf.Entanglement))
+	for _, ent := range conf.Entanglement {
+		var keep []int
+		switch ent.Partition {
+		case "central":
+			keep = []int{0}
+		case "half-bath":
+			for j := 1; j <= conf.BathCount/2; j++ {
+				keep = append(keep, j)
+			}
+		case "sites":
+			seen := make(map[int]bool)
+			for _, site := range ent.Sites {
+				if site < 0 || site >= sites || seen[site] {
+					panic(fmt.Sprintf("entanglement sites should be distinct indices in [0, %v], got %v", sites-1, ent.Sites))
+				}
+				seen[site] = true
+			}
+			keep = ent.Sites
+		default:
+			panic("unknown partition: " + ent.Partition)
+		}
+		if len(keep) == 0 || len(keep) == sites {
+			panic("the " + ent.Partition + " partition should keep some but not all of the sites")
+		}
+		quantities := ent.Quantities
+		if len(quantities) == 0 {
+			quantities = []string{"vonneumann", "renyi2", "purity", "spectrum"}
+		}
+		for _, q := range quantities {
+			switch q {
+			case "vonneumann", "renyi2", "purity", "spectrum":
+			default:
+				panic("unknown entanglement measure: " + q)
+			}
+		}
+
+		var labels []string
+		for _, q := range quantities {
+			if q == "spectrum" {
+				for k := 0; k < int(math.Pow(float64(localDim), float64(len(keep)))); k++ {
+					labels = append(labels, fmt.Sprintf("lambda_%v(%v)", k, ent.Partition))
+				}
+				continue
+			}
+			labels = append(labels, fmt.Sprintf("%v(%v)", q, ent.Partition))
+		}
+
+		series = append(series, stateSeries{labels: labels, eval: func(_ float64, state []complex128) []float64 {
+			if indices != nil {
+				state = cs.EmbedInFullSpace(state, indices, fullDim)
+			}
+			rho := cs.ReducedDensityMatrix(state, keep, sites, localDim)
+			var values []float64
+			for _, q := range quantities {
+				switch q {
+				case "vonneumann":
+					values = append(values, rho.VonNeumannEntropy())
+				case "renyi2":
+					values = append(values, rho.RenyiEntropy(2))
+				case "purity":
+					values = append(values, rho.Purity())
+				case "spectrum":
+					values = append(values, rho.Eigenvalues()...)
+				}
+			}
+			return values
+		}})
+	}
+	return series
+}
+
+// prepareLoschmidt returns the return probability and the Loschmidt echo against the perturbation requested in the config
+func prepareLoschmidt(s *cs.System, initialKet *mat.VecDense, eigen cs.Eigen) []stateSeries {
+	lc := s.PhysicsConfig.Loschmidt
+	gramMatrix := cs.Grammian(initialKet, eigen.EigenVectors)
+	var series []stateSeries
+	if lc.ReturnProbability {
+		series = append(series, stateSeries{labels: []string{"return probability"}, eval: func(time float64, _ []complex128) []float64 {
+			return []float64{cs.ReturnProbability(time, eigen.EigenValues, gramMatrix)}
+		}})
+	}
+	if lc.TiltAngleOffset == 0 && lc.Jitter == 0 {
+		return series
+	}
+
+	perturbed := perturbedSystem(s, lc)
+	perturbedEigen := solveEigenProblem(perturbed)
+	perturbedGram := cs.Grammian(initialKet, perturbedEigen.EigenVectors)
+	label := fmt.Sprintf("loschmidt echo (tilt %+v, jitter %+v at %v)", lc.TiltAngleOffset, lc.Jitter, lc.JitterSlot)
+	series = append(series, stateSeries{labels: []string{label}, eval: func(time float64, state []complex128) []float64 {
+		return []float64{cs.LoschmidtEcho(state, initialKet, time, perturbedEigen, perturbedGram)}
+	}})
+	return series
+}
+
+// perturbedSystem returns a copy of the system with the tilt angle shifted and the coupling at JitterSlot shifted by Jitter
+func perturbedSystem(s *cs.System, lc cs.LoschmidtConfig) *cs.System {
+	conf := s.PhysicsConfig
+	bath := make([]cs.State, len(s.Bath))
+	copy(bath, s.Bath)
+	if lc.TiltAngleOffset != 0 {
+		if len(conf.InteractionCoefficients) > 0 {
+			panic("a tilt angle offset needs couplings derived from a geometry, not interactioncoefficients")
+		}
+		requireTilt(conf, "a tilt angle offset")
+		conf.TiltAngle += lc.TiltAngleOffset
+		bath = cs.BathStates(conf)
+	}
+	p := &cs.System{
+		CentralSpin:      s.CentralSpin,
+		Bath:             bath,
+		PhysicsConfig:    conf,
+		DownSpins:        s.DownSpins,
+		QuantisationAxis: cs.QuantisationAxis(conf),
+	}
+	if lc.Jitter != 0 {
+		if lc.JitterSlot < 1 || lc.JitterSlot > len(bath) {
+			panic("jitterslot should point at a bath spin")
+		}
+		coefficients := make([]float64, len(bath)+1)
+		for j := 1; j <= len(bath); j++ {
+			coefficients[j] = p.InteractionAt(j)
+		}
+		coefficients[lc.JitterSlot] += lc.Jitter
+		p.PhysicsConfig.InteractionCoefficients = coefficients
+	}
+	return p
+}
+
+// requireTilt panics when orientation.field is set, since the field direction then overrides the tilt angle that 'feature' varies
+func requireTilt(conf cs.PhysicsConfig, feature string) {
+	if len(conf.Orientation.Field) > 0 {
+		panic(feature + " varies the tilt angle, which orientation.field overrides")
+	}
+}
+
+func spinOperator(name string, spin float64) *mat.Dense {
+	switch name {
+	case "Sz":
+		return cs.Sz(spin)
+	case "Sp":
+		return cs.Sp(spin)
+	case "Sm":
+		return cs.Sm(spin)
+	default:
+		return cs.Id(spin)
+	}
+}
+
+func modeOperator(name string, maxBosons int) *mat.Dense {
+	switch name {
+	case "a":
+		return cs.Annihilation(maxBosons)
+	case "ad":
+		return cs.Creation(maxBosons)
+	case "n":
+		return cs.Number(maxBosons)
+	default:
+		return cs.BosonId(maxBosons)
+	}
+}
+
 func solveEigenProblem(s *cs.System) cs.Eigen {
+	return s.Diagonalize(systemHamiltonian(s))
+}
+
+// systemHamiltonian returns the Hamiltonian at the configured fields, restricted to the sector of DownSpins if there is one
+func systemHamiltonian(s *cs.System) *mat.SymDense {
 	b := s.PhysicsConfig.BathMagneticField
 	b0 := s.PhysicsConfig.CentralMagneticField
 	if s.DownSpins < 1 {
-		hamiltonian := s.Hamiltonian(b0, b)
-		return s.Diagonalize(hamiltonian)
+		return s.Hamiltonian(b0, b)
 	}
 	indices := cs.BasisIndices(s.PhysicsConfig.BathCount+1, s.DownSpins)
-	hamiltonian := s.HamiltonianInBase(b0, b, indices)
+	return s.HamiltonianInBase(b0, b, indices)
+}
 
-	return s.Diagonalize(hamiltonian)
+// stateSeries evaluates a group of named real quantities on the state evolved up to a given time
+type stateSeries struct {
+	labels []string
+	eval   func(time float64, state []complex128) []float64
 }
 
-func prepareInitialKet(s *cs.System) *mat.VecDense {
-	fullKet := mat.NewVecDense(int(math.Pow(2*s.PhysicsConfig.Spin+1, float64(len(s.PhysicsConfig.InitialKet)))), cs.ManyBodyVector(s.PhysicsConfig.InitialKet, int(2*s.PhysicsConfig.Spin+1)))
-	if s.DownSpins < 1 {
-		return fullKet
-	}
-	indices := cs.BasisIndices(s.PhysicsConfig.BathCount+1, s.DownSpins)
-	ketData := make([]float64, len(indices))
-	for i, index := range indices {
-		ketData[i] = fullKet.AtVec(index)
-	}
-	return mat.NewVecDense(len(indices), ketData)
+func scalarSeries(label string, f func(state []complex128) float64) stateSeries {
+	return stateSeries{labels: []string{label}, eval: func(_ float64, state []complex128) []float64 {
+		return []float64{f(state)}
+	}}
 }
 
-func downSpins(ket string) int {
-	downSpins := 0
-	for s := range ket {
-		if ket[s] == 'd' {
-			downSpins++
+// evolveSeries evolves the initial ket over TimeRange steps of Dt and evaluates every series on the evolved state.
+// The state is computed once per time step. The returned curves and labels follow the order of the series and their labels
+func evolveSeries(conf cs.PhysicsConfig, initialKet *mat.VecDense, eigen cs.Eigen, series []stateSeries, progress bool) ([]plotter.XYs, []string) {
+	gramMatrix := cs.Grammian(initialKet, eigen.EigenVectors)
+	var labels []string
+	for _, s := range series {
+		labels = append(labels, s.labels...)
+	}
+	xyss := timeSeries(conf, len(labels), progress, func(time float64) []float64 {
+		state := cs.Evolve(initialKet, time, eigen.EigenValues, eigen.EigenVectors, gramMatrix)
+		values := make([]float64, 0, len(labels))
+		for _, s := range series {
+			values = append(values, s.eval(time, state)...)
 		}
+		return values
+	})
+	return xyss, labels
+}
+
+// timeSeries evaluates 'count' real quantities at TimeRange steps of Dt, in parallel over the time steps.
+// With progress set, a progress bar follows the time steps
+func timeSeries(conf cs.PhysicsConfig, count int, progress bool, eval func(time float64) []float64) []plotter.XYs {
+	xyss := make([]plotter.XYs, count)
+	for i := range xyss {
+		xyss[i] = make(plotter.XYs, conf.TimeRange)
+	}
+
+	times := make(chan int, conf.TimeRange)
+	for t := 0; t < conf.TimeRange; t++ {
+		times <- t
+	}
+	close(times)
+
+	var progressBar *uiprogress.Bar
+	if progress {
+		uiprogress.Start()
+		defer uiprogress.Stop()
+		progressBar = uiprogress.AddBar(conf.TimeRange).AppendCompleted().PrependElapsed()
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range times {
+				evolutionTime := conf.Dt * float64(t)
+				for i, value := range eval(evolutionTime) {
+					xyss[i][t] = plotter.XY{X: evolutionTime / (2.0 * math.Pi), Y: value}
+				}
+				if progressBar != nil {
+					progressBar.Incr()
+				}
+			}
+		}()
 	}
-	return downSpins
+	wg.Wait()
+	return xyss
 }